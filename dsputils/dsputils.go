@@ -73,6 +73,11 @@ func ZeroPad2(x []complex128) []complex128 {
 	return ZeroPad(x, NextPowerOf2(len(x)))
 }
 
+// ZeroPadToPow2 returns ZeroPadF of x, with the length as the next power of 2 >= len(x).
+func ZeroPadToPow2(x []float64) []float64 {
+	return ZeroPadF(x, NextPowerOf2(len(x)))
+}
+
 // ToComplex2 returns the complex equivalent of the real-valued matrix.
 func ToComplex2(x [][]float64) [][]complex128 {
 	y := make([][]complex128, len(x))
@@ -56,3 +56,24 @@ func TestSegment(t *testing.T) {
 		}
 	}
 }
+
+func TestNextPowerOf2(t *testing.T) {
+	for _, v := range [][2]int{{1, 1}, {2, 2}, {3, 4}, {1000, 1024}, {1024, 1024}, {1025, 2048}} {
+		if n := NextPowerOf2(v[0]); n != v[1] {
+			t.Error("NextPowerOf2 error: input:", v[0], ", expected:", v[1], ", output:", n)
+		}
+	}
+}
+
+func TestZeroPadToPow2(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5}
+	v := ZeroPadToPow2(x)
+	if !PrettyClose(v, []float64{1, 2, 3, 4, 5, 0, 0, 0}) {
+		t.Error("ZeroPadToPow2 error: output:", v)
+	}
+
+	x = []float64{1, 2, 3, 4}
+	if v = ZeroPadToPow2(x); len(v) != 4 {
+		t.Error("ZeroPadToPow2 error: expected length 4, output:", v)
+	}
+}
@@ -17,6 +17,10 @@
 // Package spectral provides spectral analysis functions for digital signal processing.
 package spectral
 
+import (
+	"math"
+)
+
 // Segment x segmented into segments of length size with specified noverlap.
 // Number of segments returned is (len(x) - size) / (size - noverlap) + 1.
 func Segment(x []float64, size, noverlap int) [][]float64 {
@@ -45,3 +49,38 @@ func Segment(x []float64, size, noverlap int) [][]float64 {
 
 	return r
 }
+
+// Frames calls apply with each frame of frameSize values of x, frames
+// starting hop values apart, until a frame reaches the end of x. The final
+// frame is zero-padded if x runs out before it is full. The frame slice is
+// reused between calls, so apply must copy it to keep it.
+func Frames(x []float64, frameSize, hop int, apply func(frame []float64)) {
+	if frameSize <= 0 || hop <= 0 {
+		return
+	}
+
+	frame := make([]float64, frameSize)
+	for offset := 0; offset < len(x); offset += hop {
+		n := copy(frame, x[offset:])
+		for i := n; i < frameSize; i++ {
+			frame[i] = 0
+		}
+		apply(frame)
+		if offset+frameSize >= len(x) {
+			break
+		}
+	}
+}
+
+// Goertzel returns the power of x at the frequency freq, for x sampled at
+// sampleRate, using the Goertzel algorithm. It equals the squared magnitude
+// of the DFT of x at freq, which need not fall on a DFT bin, and is cheaper
+// than an FFT when only a few frequencies are needed.
+func Goertzel(x []float64, freq, sampleRate float64) float64 {
+	coef := 2 * math.Cos(2*math.Pi*freq/sampleRate)
+	var s1, s2 float64
+	for _, v := range x {
+		s1, s2 = v+coef*s1-s2, s1
+	}
+	return s1*s1 + s2*s2 - coef*s1*s2
+}
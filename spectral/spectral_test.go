@@ -17,9 +17,12 @@
 package spectral
 
 import (
+	"math"
+	"math/cmplx"
 	"testing"
 
 	"github.com/mjibson/go-dsp/dsputils"
+	"github.com/mjibson/go-dsp/fft"
 )
 
 type segmentTest struct {
@@ -65,3 +68,75 @@ func TestSegment(t *testing.T) {
 		}
 	}
 }
+
+type framesTest struct {
+	size,
+	hop int
+	out [][]float64
+}
+
+var framesTests = []framesTest{
+	{
+		4, 4,
+		[][]float64{
+			{1, 2, 3, 4},
+			{5, 6, 7, 8},
+			{9, 10, 0, 0},
+		},
+	},
+	{
+		4, 3,
+		[][]float64{
+			{1, 2, 3, 4},
+			{4, 5, 6, 7},
+			{7, 8, 9, 10},
+		},
+	},
+	{
+		6, 2,
+		[][]float64{
+			{1, 2, 3, 4, 5, 6},
+			{3, 4, 5, 6, 7, 8},
+			{5, 6, 7, 8, 9, 10},
+		},
+	},
+	{
+		16, 8,
+		[][]float64{
+			{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 0, 0, 0, 0, 0, 0},
+		},
+	},
+}
+
+func TestFrames(t *testing.T) {
+	x := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	for _, v := range framesTests {
+		var o [][]float64
+		Frames(x, v.size, v.hop, func(frame []float64) {
+			o = append(o, append([]float64(nil), frame...))
+		})
+		if !dsputils.PrettyClose2F(o, v.out) {
+			t.Error("Frames error\n  output:", o, "\nexpected:", v.out)
+		}
+	}
+
+	Frames(nil, 4, 2, func([]float64) {
+		t.Error("Frames of no data should not call apply")
+	})
+}
+
+func TestGoertzel(t *testing.T) {
+	x := make([]float64, 64)
+	for i := range x {
+		x[i] = math.Sin(2*math.Pi*5*float64(i)/64) + 0.5*math.Cos(2*math.Pi*12*float64(i)/64)
+	}
+	X := fft.FFTReal(x)
+
+	for _, k := range []int{0, 5, 12, 20} {
+		expected := math.Pow(cmplx.Abs(X[k]), 2)
+		if p := Goertzel(x, float64(k)*1000/64, 1000); math.Abs(p-expected) > 1e-6 {
+			t.Errorf("Goertzel at bin %d: expected %v, got %v", k, expected, p)
+		}
+	}
+}
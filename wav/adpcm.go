@@ -0,0 +1,109 @@
+package wav
+
+import (
+	"errors"
+)
+
+var imaIndexTable = [8]int{-1, -1, -1, -1, 2, 4, 6, 8}
+
+var imaStepTable = [89]int{
+	7, 8, 9, 10, 11, 12, 13, 14, 16, 17,
+	19, 21, 23, 25, 28, 31, 34, 37, 41, 45,
+	50, 55, 60, 66, 73, 80, 88, 97, 107, 118,
+	130, 143, 157, 173, 190, 209, 230, 253, 279, 307,
+	337, 371, 408, 449, 494, 544, 598, 658, 724, 796,
+	876, 963, 1060, 1166, 1282, 1411, 1552, 1707, 1878, 2066,
+	2272, 2499, 2749, 3024, 3327, 3660, 4026, 4428, 4871, 5358,
+	5894, 6484, 7132, 7845, 8630, 9493, 10442, 11487, 12635, 13899,
+	15289, 16818, 18500, 20350, 22385, 24623, 27086, 29794, 32767,
+}
+
+// imaDecoder holds the state of one channel of an IMA ADPCM stream.
+type imaDecoder struct {
+	predictor int
+	index     int
+}
+
+// next decodes a 4-bit code and returns the next sample.
+func (d *imaDecoder) next(code uint8) int {
+	step := imaStepTable[d.index]
+	diff := step >> 3
+	if code&1 != 0 {
+		diff += step >> 2
+	}
+	if code&2 != 0 {
+		diff += step >> 1
+	}
+	if code&4 != 0 {
+		diff += step
+	}
+	if code&8 != 0 {
+		d.predictor -= diff
+	} else {
+		d.predictor += diff
+	}
+
+	if d.predictor > 32767 {
+		d.predictor = 32767
+	} else if d.predictor < -32768 {
+		d.predictor = -32768
+	}
+	d.index += imaIndexTable[code&7]
+	if d.index < 0 {
+		d.index = 0
+	} else if d.index > 88 {
+		d.index = 88
+	}
+
+	return d.predictor
+}
+
+// decodeIMAADPCM decodes the blocks of an IMA ADPCM data chunk to 16-bit
+// samples. Each block starts with a 4 byte header per channel holding the
+// first sample and step index, followed by 4 byte groups of 8 codes per
+// channel in turn, low nibble first.
+func decodeIMAADPCM(data []byte, numChannels, blockAlign int) ([][]int, error) {
+	headerSize := 4 * numChannels
+	if numChannels == 0 || blockAlign <= headerSize {
+		return nil, errors.New("wav: invalid IMA ADPCM block align")
+	}
+
+	var samples [][]int
+	for len(data) > headerSize {
+		block := data
+		if len(block) > blockAlign {
+			block = block[:blockAlign]
+		}
+		data = data[len(block):]
+
+		decoders := make([]imaDecoder, numChannels)
+		first := make([]int, numChannels)
+		for ch := range decoders {
+			decoders[ch].predictor = int(bLEtoInt16(block, 4*ch))
+			decoders[ch].index = int(block[4*ch+2])
+			if decoders[ch].index > 88 {
+				return nil, errors.New("wav: invalid IMA ADPCM step index")
+			}
+			first[ch] = decoders[ch].predictor
+		}
+		samples = append(samples, first)
+
+		codes := block[headerSize:]
+		groups := len(codes) / headerSize
+		for g := 0; g < groups; g++ {
+			chunk := make([][]int, 8)
+			for i := range chunk {
+				chunk[i] = make([]int, numChannels)
+			}
+			for ch := range decoders {
+				for j, b := range codes[(g*numChannels+ch)*4 : (g*numChannels+ch+1)*4] {
+					chunk[2*j][ch] = decoders[ch].next(b & 0x0f)
+					chunk[2*j+1][ch] = decoders[ch].next(b >> 4)
+				}
+			}
+			samples = append(samples, chunk...)
+		}
+	}
+
+	return samples, nil
+}
@@ -0,0 +1,93 @@
+package wav
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// encodeIMAADPCM encodes mono 16-bit samples as IMA ADPCM blocks of
+// blockAlign bytes.
+func encodeIMAADPCM(samples []int, blockAlign int) []byte {
+	perBlock := (blockAlign-4)*2 + 1
+	var out []byte
+	for len(samples) > 0 {
+		n := perBlock
+		if n > len(samples) {
+			n = len(samples)
+		}
+		block := samples[:n]
+		samples = samples[n:]
+
+		d := imaDecoder{predictor: block[0]}
+		out = append(out, uint8(block[0]), uint8(block[0]>>8), 0, 0)
+		var codes []uint8
+		for _, s := range block[1:] {
+			step := imaStepTable[d.index]
+			diff := s - d.predictor
+			var code uint8
+			if diff < 0 {
+				code = 8
+				diff = -diff
+			}
+			for bit := uint8(4); bit > 0; bit >>= 1 {
+				if diff >= step {
+					code |= bit
+					diff -= step
+				}
+				step >>= 1
+			}
+			d.next(code)
+			codes = append(codes, code)
+		}
+		for len(codes)%8 != 0 {
+			codes = append(codes, 0)
+		}
+		for i := 0; i < len(codes); i += 2 {
+			out = append(out, codes[i]|codes[i+1]<<4)
+		}
+	}
+	return out
+}
+
+func TestReadWavIMAADPCM(t *testing.T) {
+	const sampleRate, blockAlign = 22050, 256
+	perBlock := (blockAlign-4)*2 + 1
+	tone := sineData(440, sampleRate, 4*perBlock, 0.5)
+	pcm := make([]int, len(tone))
+	for i := range tone {
+		pcm[i] = tone[i][0]
+	}
+
+	var format bytes.Buffer
+	write(&format, uint16(formatIMAADPCM))
+	write(&format, uint16(1))
+	write(&format, uint32(sampleRate))
+	write(&format, uint32(sampleRate*blockAlign/perBlock))
+	write(&format, uint16(blockAlign))
+	write(&format, uint16(4))
+	write(&format, uint16(2))
+	write(&format, uint16(perBlock))
+	file := riff(chunk("fmt ", format.Bytes()), chunk("data", encodeIMAADPCM(pcm, blockAlign)))
+
+	wav, err := ReadWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadWav returned an error: %v", err)
+	}
+	if wav.AudioFormat != formatPCM || wav.BitsPerSample != 16 || wav.NumSamples != len(pcm) {
+		t.Fatalf("Expected %d 16-bit PCM samples. Got %+v", len(pcm), wav.WavHeader)
+	}
+	if len(wav.Data16) != len(pcm) {
+		t.Fatalf("Expected Data16 to hold %d samples, got %d", len(pcm), len(wav.Data16))
+	}
+
+	var signal, noise float64
+	for i, s := range pcm {
+		d := float64(int(wav.Data16[i][0]) - s)
+		signal += float64(s) * float64(s)
+		noise += d * d
+	}
+	if ratio := 10 * math.Log10(signal/noise); ratio < 20 {
+		t.Fatalf("Expected the decoded tone to be recognizable, SNR is only %v dB", ratio)
+	}
+}
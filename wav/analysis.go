@@ -0,0 +1,306 @@
+package wav
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/bits"
+
+	"github.com/mjibson/go-dsp/spectral"
+)
+
+// DetectClicks returns the sample indices at which the absolute first
+// difference of any channel exceeds threshold, indicating impulsive noise
+// such as clicks and pops. threshold is relative to full scale, so 0.5
+// means a jump of a quarter of the full peak-to-peak range.
+func (w *Wav) DetectClicks(threshold float64) []int {
+	var clicks []int
+	for i := 1; i < len(w.Data); i++ {
+		for ch := 0; ch < int(w.NumChannels); ch++ {
+			d := w.normalize(w.Data[i][ch]) - w.normalize(w.Data[i-1][ch])
+			if math.Abs(d) > threshold {
+				clicks = append(clicks, i)
+				break
+			}
+		}
+	}
+	return clicks
+}
+
+// clickWindow is the largest gap, in samples, between detections that
+// RemoveClicks treats as part of the same click.
+const clickWindow = 8
+
+// RemoveClicks replaces the clicks found by DetectClicks with a straight line
+// between the last good sample before and the first good sample after each
+// click. A click running to the end of the data is replaced by holding the
+// last good sample.
+func (w *Wav) RemoveClicks(threshold float64) {
+	clicks := w.DetectClicks(threshold)
+	for i := 0; i < len(clicks); {
+		first, last := clicks[i], clicks[i]
+		for i++; i < len(clicks) && clicks[i]-last <= clickWindow; i++ {
+			last = clicks[i]
+		}
+
+		before, after := first-1, last+1
+		for ch := 0; ch < int(w.NumChannels); ch++ {
+			a := w.normalize(w.Data[before][ch])
+			b := a
+			if after < len(w.Data) {
+				b = w.normalize(w.Data[after][ch])
+			}
+			for j := before + 1; j < after; j++ {
+				t := float64(j-before) / float64(after-before)
+				w.set(j, ch, w.denormalize(a+(b-a)*t))
+			}
+		}
+	}
+}
+
+// AmplitudeHistogram returns the number of mono samples falling into each of
+// bins equal-width bins spanning full scale, from negative to positive.
+func (w *Wav) AmplitudeHistogram(bins int) []int {
+	if bins <= 0 {
+		return nil
+	}
+
+	hist := make([]int, bins)
+	for _, x := range w.mono() {
+		b := int((x + 1) / 2 * float64(bins))
+		if b < 0 {
+			b = 0
+		} else if b >= bins {
+			b = bins - 1
+		}
+		hist[b]++
+	}
+	return hist
+}
+
+// DCOffset returns the mean of each channel of w relative to full scale, so
+// a 16-bit channel averaging 16384 reports 0.5.
+func (w *Wav) DCOffset() []float64 {
+	offsets := make([]float64, w.NumChannels)
+	if len(w.Data) == 0 {
+		return offsets
+	}
+	for _, sample := range w.Data {
+		for ch, v := range sample {
+			offsets[ch] += w.normalize(v)
+		}
+	}
+	for ch := range offsets {
+		offsets[ch] /= float64(len(w.Data))
+	}
+	return offsets
+}
+
+// EffectiveBitDepth estimates the resolution actually used by the samples of
+// w: BitsPerSample less the number of low bits that are zero in every
+// sample. A 16-bit file made from 8-bit content reports 8, and a silent file
+// reports 0.
+func (w *Wav) EffectiveBitDepth() int {
+	var used uint
+	for _, sample := range w.Data {
+		for _, v := range sample {
+			if w.BitsPerSample == 8 {
+				v -= 128
+			}
+			used |= uint(v)
+		}
+	}
+	if used == 0 {
+		return 0
+	}
+	depth := int(w.BitsPerSample) - bits.TrailingZeros(used)
+	if depth < 1 {
+		depth = 1
+	}
+	return depth
+}
+
+// Peak returns the largest absolute sample value of w relative to full
+// scale, over all channels.
+func (w *Wav) Peak() float64 {
+	var peak float64
+	for _, sample := range w.Data {
+		for _, v := range sample {
+			peak = math.Max(peak, math.Abs(w.normalize(v)))
+		}
+	}
+	return peak
+}
+
+// RMS returns the root mean square of w relative to full scale, over all
+// channels.
+func (w *Wav) RMS() float64 {
+	var sum float64
+	var n int
+	for _, sample := range w.Data {
+		for _, v := range sample {
+			x := w.normalize(v)
+			sum += x * x
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+// ShortTimeEnergy returns the energy, the sum of squared samples relative to
+// full scale, of each frame of frameSize samples of the mono signal of w,
+// frames starting hop samples apart. It is a cheaper measure than the RMS of
+// each frame for telling speech from silence. The final frame is zero-padded.
+func (w *Wav) ShortTimeEnergy(frameSize, hop int) []float64 {
+	var energy []float64
+	spectral.Frames(w.mono(), frameSize, hop, func(frame []float64) {
+		var sum float64
+		for _, v := range frame {
+			sum += v * v
+		}
+		energy = append(energy, sum)
+	})
+	return energy
+}
+
+// CrestFactor returns the ratio of Peak to RMS in dB: about 3 dB for a sine,
+// 0 dB for a square wave and higher the more dynamic the signal is. It
+// returns 0 for silence.
+func (w *Wav) CrestFactor() float64 {
+	rms := w.RMS()
+	if rms == 0 {
+		return 0
+	}
+	return 20 * math.Log10(w.Peak()/rms)
+}
+
+// PhaseCorrelation returns the normalized correlation between the left and
+// right channels of a stereo w: 1 for identical channels, -1 for inverted
+// ones that cancel when mixed to mono, and near 0 for unrelated ones. It
+// returns 0 if w is not stereo or either channel is silent.
+func (w *Wav) PhaseCorrelation() float64 {
+	if w.NumChannels != 2 {
+		return 0
+	}
+	var lr, ll, rr float64
+	for _, sample := range w.Data {
+		l, r := w.normalize(sample[0]), w.normalize(sample[1])
+		lr += l * r
+		ll += l * l
+		rr += r * r
+	}
+	if ll == 0 || rr == 0 {
+		return 0
+	}
+	return lr / math.Sqrt(ll*rr)
+}
+
+// EffectiveChannels returns the number of channels of w that are not
+// digitally silent, e.g. 1 for a stereo file with an all-zero channel.
+func (w *Wav) EffectiveChannels() int {
+	used := make([]bool, w.NumChannels)
+	n := 0
+	for _, sample := range w.Data {
+		for ch, v := range sample {
+			if !used[ch] && w.normalize(v) != 0 {
+				used[ch] = true
+				n++
+			}
+		}
+		if n == len(used) {
+			break
+		}
+	}
+	return n
+}
+
+// CompareWavs returns the largest absolute difference and the root mean
+// square difference between the samples of a and b, in sample units. It
+// returns an error if a and b differ in format or length.
+func CompareWavs(a, b *Wav) (maxAbsDiff int, rmse float64, err error) {
+	if a.NumChannels != b.NumChannels || a.SampleRate != b.SampleRate || a.BitsPerSample != b.BitsPerSample {
+		return 0, 0, errors.New("wav: formats differ")
+	}
+	if len(a.Data) != len(b.Data) {
+		return 0, 0, errors.New("wav: lengths differ")
+	}
+
+	var sum float64
+	for i, sample := range a.Data {
+		for ch, v := range sample {
+			d := v - b.Data[i][ch]
+			if d < 0 {
+				d = -d
+			}
+			maxAbsDiff = max(maxAbsDiff, d)
+			sum += float64(d) * float64(d)
+		}
+	}
+	if n := len(a.Data) * int(a.NumChannels); n > 0 {
+		rmse = math.Sqrt(sum / float64(n))
+	}
+	return maxAbsDiff, rmse, nil
+}
+
+// AssertLosslessRoundTrip encodes the integer PCM w with Write, reads the
+// result back and returns an error describing the first difference if the
+// format or any sample did not survive unchanged. It is meant for test
+// harnesses checking the encoder.
+func AssertLosslessRoundTrip(w *Wav) error {
+	return losslessRoundTrip(w, (*Wav).Write)
+}
+
+// losslessRoundTrip is AssertLosslessRoundTrip with the encoder as a
+// parameter.
+func losslessRoundTrip(w *Wav, encode func(*Wav, io.Writer) error) error {
+	if w.AudioFormat != formatPCM && w.AudioFormat != 0 {
+		return newError(ErrUnsupportedFormat, "wav: lossless round trip needs integer PCM")
+	}
+
+	var buf bytes.Buffer
+	if err := encode(w, &buf); err != nil {
+		return fmt.Errorf("wav: round trip encode: %v", err)
+	}
+	reread, err := ReadWav(&buf)
+	if err != nil {
+		return fmt.Errorf("wav: round trip decode: %v", err)
+	}
+
+	if reread.NumChannels != w.NumChannels || reread.SampleRate != w.SampleRate || reread.BitsPerSample != w.BitsPerSample {
+		return fmt.Errorf("wav: round trip changed the format from %d channels of %d-bit at %d Hz to %d channels of %d-bit at %d Hz",
+			w.NumChannels, w.BitsPerSample, w.SampleRate, reread.NumChannels, reread.BitsPerSample, reread.SampleRate)
+	}
+	if len(reread.Data) != len(w.Data) {
+		return fmt.Errorf("wav: round trip changed the length from %d to %d samples", len(w.Data), len(reread.Data))
+	}
+	for i, sample := range w.Data {
+		for ch, v := range sample {
+			if got := reread.Data[i][ch]; got != v {
+				return fmt.Errorf("wav: round trip changed sample %d channel %d from %d to %d", i, ch, v, got)
+			}
+		}
+	}
+	return nil
+}
+
+// ContentHash returns the 64-bit FNV-1a hash of the sample values of w, in
+// order. Metadata is not hashed, so files holding the same audio hash equal
+// whatever their headers and extra chunks.
+func (w *Wav) ContentHash() uint64 {
+	h := fnv.New64a()
+	var b [8]byte
+	for _, sample := range w.Data {
+		for _, v := range sample {
+			binary.LittleEndian.PutUint64(b[:], uint64(v))
+			h.Write(b[:])
+		}
+	}
+	return h.Sum64()
+}
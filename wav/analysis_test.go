@@ -0,0 +1,270 @@
+package wav
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDetectClicks(t *testing.T) {
+	w := testWav(44100, sineData(440, 44100, 1000, 0.25))
+	if clicks := w.DetectClicks(0.5); len(clicks) != 0 {
+		t.Fatalf("Expected no clicks in a clean sine, got %v", clicks)
+	}
+
+	w.set(500, 0, 32000)
+	clicks := w.DetectClicks(0.5)
+	if len(clicks) == 0 || clicks[0] != 500 {
+		t.Fatalf("Expected a click at sample 500, got %v", clicks)
+	}
+}
+
+func TestRemoveClicks(t *testing.T) {
+	clean := sineData(440, 44100, 1000, 0.25)
+	w := testWav(44100, sineData(440, 44100, 1000, 0.25))
+	w.set(500, 0, 32000)
+
+	w.RemoveClicks(0.5)
+	if clicks := w.DetectClicks(0.5); len(clicks) != 0 {
+		t.Fatalf("Expected no clicks after removal, got %v", clicks)
+	}
+	for i := 495; i < 505; i++ {
+		if d := w.Data[i][0] - clean[i][0]; d > 100 || d < -100 {
+			t.Fatalf("Sample %d not restored. Expected ~%d. Got %d", i, clean[i][0], w.Data[i][0])
+		}
+	}
+
+	// A click at the last sample has no good sample after it.
+	w.set(999, 0, 32000)
+	w.RemoveClicks(0.5)
+	if w.Data[999][0] != w.Data[998][0] {
+		t.Fatalf("Expected the last sample to hold %d. Got %d", w.Data[998][0], w.Data[999][0])
+	}
+}
+
+func TestAmplitudeHistogram(t *testing.T) {
+	data := make([][]int, 100)
+	for i := range data {
+		data[i] = []int{16384}
+	}
+	hist := testWav(44100, data).AmplitudeHistogram(8)
+	if len(hist) != 8 {
+		t.Fatalf("Expected 8 bins, got %d", len(hist))
+	}
+	for b, n := range hist {
+		if b == 6 && n != 100 {
+			t.Fatalf("Expected all 100 samples in bin 6, got %d", n)
+		} else if b != 6 && n != 0 {
+			t.Fatalf("Expected bin %d to be empty, got %d", b, n)
+		}
+	}
+
+	data8 := newWav(WavHeader{AudioFormat: 1, NumChannels: 1, SampleRate: 8000, BitsPerSample: 8}, [][]int{{192}, {192}})
+	if hist := data8.AmplitudeHistogram(8); hist[6] != 2 {
+		t.Fatalf("Expected 8-bit samples to be normalized into bin 6, got %v", hist)
+	}
+}
+
+func TestDCOffset(t *testing.T) {
+	tone := sineData(100, 8000, 8000, 0.5)
+	data := make([][]int, len(tone))
+	for i := range tone {
+		data[i] = []int{tone[i][0] + 8192, tone[i][0]}
+	}
+
+	offsets := testWav(8000, data).DCOffset()
+	if len(offsets) != 2 {
+		t.Fatalf("Expected 2 offsets, got %d", len(offsets))
+	}
+	if math.Abs(offsets[0]-0.25) > 1e-4 || math.Abs(offsets[1]) > 1e-4 {
+		t.Fatalf("Expected offsets [0.25 0]. Got %v", offsets)
+	}
+}
+
+func TestEffectiveBitDepth(t *testing.T) {
+	tone := sineData(440, 8000, 1000, 0.9)
+	if depth := testWav(8000, tone).EffectiveBitDepth(); depth != 16 {
+		t.Fatalf("Expected a 16-bit tone to use 16 bits. Got %d", depth)
+	}
+
+	upscaled := make([][]int, len(tone))
+	for i := range tone {
+		upscaled[i] = []int{tone[i][0] >> 8 << 8}
+	}
+	if depth := testWav(8000, upscaled).EffectiveBitDepth(); depth != 8 {
+		t.Fatalf("Expected 8-bit content in a 16-bit file to use 8 bits. Got %d", depth)
+	}
+
+	if depth := testWav(8000, [][]int{{0}, {0}}).EffectiveBitDepth(); depth != 0 {
+		t.Fatalf("Expected silence to use 0 bits. Got %d", depth)
+	}
+}
+
+func TestCrestFactor(t *testing.T) {
+	sine := testWav(8000, sineData(100, 8000, 8000, 0.5))
+	if math.Abs(sine.Peak()-0.5) > 1e-3 || math.Abs(sine.RMS()-0.5/math.Sqrt2) > 1e-3 {
+		t.Fatalf("Unexpected sine peak %v and RMS %v", sine.Peak(), sine.RMS())
+	}
+	if crest := sine.CrestFactor(); math.Abs(crest-3.01) > 0.05 {
+		t.Fatalf("Expected a sine to have a ~3 dB crest factor. Got %v", crest)
+	}
+
+	square := sineData(100, 8000, 8000, 0.5)
+	for i := range square {
+		if square[i][0] >= 0 {
+			square[i][0] = 16384
+		} else {
+			square[i][0] = -16384
+		}
+	}
+	if crest := testWav(8000, square).CrestFactor(); math.Abs(crest) > 0.01 {
+		t.Fatalf("Expected a square wave to have a 0 dB crest factor. Got %v", crest)
+	}
+}
+
+func TestPhaseCorrelation(t *testing.T) {
+	tone := sineData(440, 8000, 800, 0.3)
+	stereo := func(right func(v int) int) *Wav {
+		data := make([][]int, len(tone))
+		for i, sample := range tone {
+			data[i] = []int{sample[0], right(sample[0])}
+		}
+		return newWav(WavHeader{AudioFormat: 1, NumChannels: 2, SampleRate: 8000, BitsPerSample: 16}, data)
+	}
+
+	if c := stereo(func(v int) int { return v }).PhaseCorrelation(); math.Abs(c-1) > 1e-9 {
+		t.Errorf("Expected identical channels to correlate at 1, got %v", c)
+	}
+	if c := stereo(func(v int) int { return -v }).PhaseCorrelation(); math.Abs(c+1) > 1e-9 {
+		t.Errorf("Expected inverted channels to correlate at -1, got %v", c)
+	}
+	if c := testWav(8000, tone).PhaseCorrelation(); c != 0 {
+		t.Errorf("Expected 0 for a mono file, got %v", c)
+	}
+}
+
+func TestEffectiveChannels(t *testing.T) {
+	stereo := newWav(WavHeader{AudioFormat: 1, NumChannels: 2, SampleRate: 8000, BitsPerSample: 16},
+		[][]int{{0, 0}, {100, 0}, {-100, 0}})
+	if n := stereo.EffectiveChannels(); n != 1 {
+		t.Errorf("Expected 1 channel with a silent right channel, got %d", n)
+	}
+	stereo.set(2, 1, 1)
+	if n := stereo.EffectiveChannels(); n != 2 {
+		t.Errorf("Expected 2 channels, got %d", n)
+	}
+
+	// Silence in 8-bit samples is 128.
+	silent8 := newWav(WavHeader{AudioFormat: 1, NumChannels: 2, SampleRate: 8000, BitsPerSample: 8},
+		[][]int{{128, 128}, {128, 128}})
+	if n := silent8.EffectiveChannels(); n != 0 {
+		t.Errorf("Expected 0 channels for 8-bit silence, got %d", n)
+	}
+}
+
+func TestCompareWavs(t *testing.T) {
+	a := testWav(8000, sineData(440, 8000, 800, 0.3))
+	if maxAbsDiff, rmse, err := CompareWavs(a, a); err != nil || maxAbsDiff != 0 || rmse != 0 {
+		t.Fatalf("Expected a Wav to equal itself, got %d, %v, %v", maxAbsDiff, rmse, err)
+	}
+
+	b := testWav(8000, sineData(440, 8000, 800, 0.3))
+	b.set(10, 0, b.Data[10][0]+3)
+	b.set(20, 0, b.Data[20][0]-4)
+	maxAbsDiff, rmse, err := CompareWavs(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := math.Sqrt(25.0 / 800); maxAbsDiff != 4 || math.Abs(rmse-expected) > 1e-12 {
+		t.Fatalf("Expected a maximum difference of 4 and RMSE of %v, got %d and %v", expected, maxAbsDiff, rmse)
+	}
+
+	if _, _, err = CompareWavs(a, testWav(16000, sineData(440, 16000, 800, 0.3))); err == nil {
+		t.Fatal("Expected comparing different sample rates to fail")
+	}
+	if _, _, err = CompareWavs(a, testWav(8000, sineData(440, 8000, 400, 0.3))); err == nil {
+		t.Fatal("Expected comparing different lengths to fail")
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	a := testWav(8000, sineData(440, 8000, 800, 0.3))
+	b := testWav(8000, sineData(440, 8000, 800, 0.3))
+	b.Instrument = &Instrument{UnshiftedNote: 69}
+	b.RawChunks = []RawChunk{{"LIST", []byte("INFO")}}
+	b.RIFFSize = 12345
+	if a.ContentHash() != b.ContentHash() {
+		t.Fatal("Expected Wavs with the same samples but different metadata to hash equal")
+	}
+
+	b.set(400, 0, b.Data[400][0]+1)
+	if a.ContentHash() == b.ContentHash() {
+		t.Fatal("Expected Wavs with different samples to hash differently")
+	}
+}
+
+func TestShortTimeEnergy(t *testing.T) {
+	data := make([][]int, 4000)
+	for i := range data {
+		data[i] = []int{0}
+	}
+	copy(data[1000:3000], sineData(440, 8000, 2000, 0.5))
+	wav := testWav(8000, data)
+
+	energy := wav.ShortTimeEnergy(200, 100)
+	if len(energy) != 39 {
+		t.Fatalf("Expected 39 frames, got %d", len(energy))
+	}
+	for i, e := range energy {
+		start, end := i*100, i*100+200
+		switch {
+		case end <= 1000 || start >= 3000:
+			if e != 0 {
+				t.Errorf("Frame %d outside the burst has energy %v", i, e)
+			}
+		case start >= 1000 && end <= 3000:
+			// 200 samples of a sine of amplitude 0.5 have energy 25.
+			if math.Abs(e-25) > 1 {
+				t.Errorf("Frame %d inside the burst has energy %v, expected about 25", i, e)
+			}
+		}
+	}
+}
+
+func TestAssertLosslessRoundTrip(t *testing.T) {
+	for _, bits := range []uint16{8, 16, 24, 32} {
+		wav := newWav(WavHeader{AudioFormat: 1, NumChannels: 2, SampleRate: 8000, BitsPerSample: bits},
+			[][]int{{0, 1}, {-1, 2}, {100, -100}})
+		if bits == 8 {
+			wav = newWav(wav.WavHeader, [][]int{{0, 255}, {128, 127}, {1, 200}})
+		}
+		if err := AssertLosslessRoundTrip(wav); err != nil {
+			t.Errorf("%d bits: unexpected error %v", bits, err)
+		}
+	}
+
+	// An encoder that corrupts the last byte of the data.
+	wav := testWav(8000, [][]int{{1}, {2}, {3}})
+	buggy := func(w *Wav, out io.Writer) error {
+		var buf bytes.Buffer
+		if err := w.Write(&buf); err != nil {
+			return err
+		}
+		b := buf.Bytes()
+		b[len(b)-1] ^= 0x80
+		_, err := out.Write(b)
+		return err
+	}
+	err := losslessRoundTrip(wav, buggy)
+	if err == nil || !strings.Contains(err.Error(), "sample 2 channel 0") {
+		t.Fatalf("Expected the corrupted sample to be reported, got %v", err)
+	}
+
+	wav.AudioFormat = formatIMAADPCM
+	if err = AssertLosslessRoundTrip(wav); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("Expected ErrUnsupportedFormat for ADPCM, got %v", err)
+	}
+}
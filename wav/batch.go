@@ -0,0 +1,53 @@
+package wav
+
+import (
+	"sync"
+)
+
+// DecodeAll reads the wav files at paths using up to concurrency goroutines
+// and returns the decoded files by path. The errors of files that could not
+// be read are returned in no particular order; each names its path.
+func DecodeAll(paths []string, concurrency int) (map[string]*Wav, []error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	type result struct {
+		path string
+		wav  *Wav
+		err  error
+	}
+	jobs := make(chan string)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				wav, err := OpenWav(path)
+				results <- result{path, wav, err}
+			}
+		}()
+	}
+	go func() {
+		for _, path := range paths {
+			jobs <- path
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	wavs := make(map[string]*Wav)
+	var errs []error
+	for r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		wavs[r.path] = r.wav
+	}
+	return wavs, errs
+}
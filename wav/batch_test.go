@@ -0,0 +1,47 @@
+package wav
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeAll(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	paths := []string{SmallWavFileName}
+	for i, freq := range []float64{110, 220, 440, 880, 1760} {
+		path := filepath.Join(dir, string(rune('a'+i))+".wav")
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = testWav(8000, sineData(freq, 8000, 4000, 0.5)).Write(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	missing := filepath.Join(dir, "missing.wav")
+
+	wavs, errs := DecodeAll(append(paths, missing), 3)
+	if len(errs) != 1 {
+		t.Fatalf("Expected one error for the missing file, got %v", errs)
+	}
+	if len(wavs) != len(paths) {
+		t.Fatalf("Expected %d decoded files, got %d", len(paths), len(wavs))
+	}
+	for _, path := range paths {
+		expected, err := OpenWav(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareWavs(t, expected, wavs[path])
+	}
+}
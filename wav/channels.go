@@ -0,0 +1,222 @@
+package wav
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// RemapChannels returns a new Wav whose channel i is channel order[i] of w.
+// Channels may be repeated or dropped, e.g. []int{1, 0} swaps a stereo pair.
+func (w *Wav) RemapChannels(order []int) (*Wav, error) {
+	if len(order) == 0 {
+		return nil, errors.New("wav: empty channel order")
+	}
+	for _, ch := range order {
+		if ch < 0 || ch >= int(w.NumChannels) {
+			return nil, errors.New("wav: channel index out of range")
+		}
+	}
+
+	data := make([][]int, len(w.Data))
+	for i, sample := range w.Data {
+		data[i] = make([]int, len(order))
+		for j, ch := range order {
+			data[i][j] = sample[ch]
+		}
+	}
+
+	header := w.WavHeader
+	header.NumChannels = uint16(len(order))
+	header.ChannelMask = 0
+	// Each channel keeps its peak.
+	header.Peaks = nil
+	if len(w.Peaks) == int(w.NumChannels) {
+		header.Peaks = make([]PeakInfo, len(order))
+		for j, ch := range order {
+			header.Peaks[j] = w.Peaks[ch]
+		}
+	}
+	return newWav(header, data), nil
+}
+
+// speakerNames are the names of the speaker positions of the bits of a
+// WAVE_FORMAT_EXTENSIBLE channel mask, lowest bit first.
+var speakerNames = []string{
+	"FL", "FR", "FC", "LFE", "BL", "BR", "FLC", "FRC", "BC",
+	"SL", "SR", "TC", "TFL", "TFC", "TFR", "TBL", "TBC", "TBR",
+}
+
+// defaultLayouts are the speaker positions assumed for each channel count
+// when a file has no channel mask.
+var defaultLayouts = map[uint16][]string{
+	1: {"FC"},
+	2: {"FL", "FR"},
+	3: {"FL", "FR", "FC"},
+	4: {"FL", "FR", "BL", "BR"},
+	5: {"FL", "FR", "FC", "BL", "BR"},
+	6: {"FL", "FR", "FC", "LFE", "BL", "BR"},
+	7: {"FL", "FR", "FC", "LFE", "BC", "SL", "SR"},
+	8: {"FL", "FR", "FC", "LFE", "BL", "BR", "SL", "SR"},
+}
+
+// ChannelLayout returns the standard name of the speaker position of each
+// channel, such as FL, FR, FC, LFE, BL and BR for 5.1. The positions come
+// from the WAVE_FORMAT_EXTENSIBLE channel mask if the file has one for as
+// many channels as it holds, and otherwise from the usual layout for the
+// channel count. Channels with no known position are named "".
+func (h WavHeader) ChannelLayout() []string {
+	names := make([]string, h.NumChannels)
+	if bits.OnesCount32(h.ChannelMask) == int(h.NumChannels) {
+		ch := 0
+		for bit, name := range speakerNames {
+			if h.ChannelMask&(1<<bit) != 0 {
+				names[ch] = name
+				ch++
+			}
+		}
+		return names
+	}
+	copy(names, defaultLayouts[h.NumChannels])
+	return names
+}
+
+// ToStereoITU downmixes 5.1 audio, with channels in the WAVE order L, R, C,
+// LFE, Ls, Rs, to stereo using the ITU-R BS.775 coefficients: the centre and
+// each surround are mixed in at -3 dB and the LFE is dropped. The result may
+// clip on loud material, as the standard does not normalize.
+func ToStereoITU(w *Wav) (*Wav, error) {
+	if w.NumChannels != 6 {
+		return nil, errors.New("wav: ITU downmix needs 5.1 input")
+	}
+
+	const g = math.Sqrt2 / 2
+	data := make([][]int, len(w.Data))
+	for i, sample := range w.Data {
+		l, r, c := w.normalize(sample[0]), w.normalize(sample[1]), w.normalize(sample[2])
+		ls, rs := w.normalize(sample[4]), w.normalize(sample[5])
+		data[i] = []int{
+			w.denormalize(l + g*c + g*ls),
+			w.denormalize(r + g*c + g*rs),
+		}
+	}
+
+	header := w.WavHeader
+	header.NumChannels = 2
+	header.Peaks = nil
+	header.ChannelMask = 0
+	return newWav(header, data), nil
+}
+
+// antiPhaseCorrelation is the PhaseCorrelation below which ToMonoSafe
+// treats the channels of a stereo file as cancelling each other.
+const antiPhaseCorrelation = -0.5
+
+// ToMonoSafe returns w mixed down to one channel by averaging its channels,
+// except that if w is stereo with channels so out of phase that averaging
+// would cancel them, judged by PhaseCorrelation, the louder channel is used
+// alone instead.
+func ToMonoSafe(w *Wav) (*Wav, error) {
+	if w.NumChannels == 0 {
+		return nil, errors.New("wav: no channels to mix down")
+	}
+
+	x := w.mono()
+	if w.NumChannels == 2 && w.PhaseCorrelation() < antiPhaseCorrelation {
+		l, r := w.channel(0), w.channel(1)
+		var el, er float64
+		for i := range l {
+			el += l[i] * l[i]
+			er += r[i] * r[i]
+		}
+		x = l
+		if er > el {
+			x = r
+		}
+	}
+
+	data := make([][]int, len(x))
+	for i, v := range x {
+		data[i] = []int{w.denormalize(v)}
+	}
+	header := w.WavHeader
+	header.NumChannels = 1
+	header.Peaks = nil
+	header.ChannelMask = 0
+	return newWav(header, data), nil
+}
+
+// MapChannels replaces each channel of w with the result of calling f on its
+// normalized samples. f must return as many samples as it is given; if it
+// does not for any channel, w is left unchanged and an error is returned.
+func (w *Wav) MapChannels(f func(ch int, samples []float64) []float64) error {
+	y := make([][]float64, w.NumChannels)
+	for ch := range y {
+		y[ch] = f(ch, w.channel(ch))
+		if len(y[ch]) != len(w.Data) {
+			return errors.New("wav: channel length changed")
+		}
+	}
+	for ch := range y {
+		w.setChannel(ch, y[ch])
+	}
+	return nil
+}
+
+// MuteChannel sets every sample of channel ch of w to silence.
+func (w *Wav) MuteChannel(ch int) error {
+	if ch < 0 || ch >= int(w.NumChannels) {
+		return errors.New("wav: channel index out of range")
+	}
+	silence := w.denormalize(0)
+	for i := range w.Data {
+		w.set(i, ch, silence)
+	}
+	return nil
+}
+
+// Planar returns a copy of Data indexed by channel, then sample.
+func (w *Wav) Planar() [][]int {
+	planar := make([][]int, w.NumChannels)
+	for ch := range planar {
+		planar[ch] = make([]int, len(w.Data))
+		for i, sample := range w.Data {
+			planar[ch][i] = sample[ch]
+		}
+	}
+	return planar
+}
+
+// SampleAt returns the value of channel ch at the fractional sample position
+// pos, relative to full scale, linearly interpolated between the neighboring
+// samples. Positions outside the data are clamped to the first or last
+// sample.
+func (w *Wav) SampleAt(ch int, pos float64) float64 {
+	if len(w.Data) == 0 {
+		return 0
+	}
+	if pos <= 0 {
+		return w.normalize(w.Data[0][ch])
+	}
+	last := len(w.Data) - 1
+	if pos >= float64(last) {
+		return w.normalize(w.Data[last][ch])
+	}
+
+	i := int(pos)
+	frac := pos - float64(i)
+	a := w.normalize(w.Data[i][ch])
+	b := w.normalize(w.Data[i+1][ch])
+	return a + (b-a)*frac
+}
+
+// XY returns the time in seconds and the value relative to full scale of
+// every sample of channel ch, ready for plotting a waveform.
+func (w *Wav) XY(ch int) (xs, ys []float64) {
+	ys = w.channel(ch)
+	xs = make([]float64, len(ys))
+	for i := range xs {
+		xs[i] = float64(i) / float64(w.SampleRate)
+	}
+	return xs, ys
+}
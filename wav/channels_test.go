@@ -0,0 +1,242 @@
+package wav
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRemapChannels(t *testing.T) {
+	w := testWav(8000, [][]int{{1, -1}, {2, -2}, {3, -3}})
+
+	swapped, err := w.RemapChannels([]int{1, 0})
+	if err != nil {
+		t.Fatalf("RemapChannels returned an error: %v", err)
+	}
+	if swapped.NumChannels != 2 || swapped.NumSamples != 3 {
+		t.Fatalf("Unexpected header: %+v", swapped.WavHeader)
+	}
+	for i := range w.Data {
+		if swapped.Data[i][0] != w.Data[i][1] || swapped.Data[i][1] != w.Data[i][0] {
+			t.Fatalf("Sample %d not swapped: %v", i, swapped.Data[i])
+		}
+		if int(swapped.Data16[i][0]) != w.Data[i][1] {
+			t.Fatalf("Data16 sample %d not swapped: %v", i, swapped.Data16[i])
+		}
+	}
+
+	// Stored peaks follow their channels.
+	w.Peaks = []PeakInfo{{0.1, 2}, {0.2, 1}}
+	remapped, err := w.RemapChannels([]int{1, 1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []PeakInfo{{0.2, 1}, {0.2, 1}, {0.1, 2}}; !reflect.DeepEqual(remapped.Peaks, expected) {
+		t.Fatalf("Expected peaks %v, got %v", expected, remapped.Peaks)
+	}
+
+	if _, err = w.RemapChannels([]int{0, 2}); err == nil {
+		t.Fatal("Expected an error for an out of range channel")
+	}
+}
+
+func TestToStereoITU(t *testing.T) {
+	// L, R, C, LFE, Ls, Rs
+	surround := newWav(WavHeader{AudioFormat: 1, NumChannels: 6, SampleRate: 48000, BitsPerSample: 16},
+		[][]int{
+			{1000, 0, 0, 0, 0, 0},
+			{0, 0, 10000, 0, 0, 0},
+			{0, 0, 0, 20000, 0, 0},
+			{0, 0, 0, 0, 10000, 0},
+			{0, 2000, 0, 0, 0, 10000},
+		})
+	stereo, err := ToStereoITU(surround)
+	if err != nil {
+		t.Fatalf("ToStereoITU returned an error: %v", err)
+	}
+	if stereo.NumChannels != 2 || stereo.BlockAlign != 4 || stereo.NumSamples != 5 {
+		t.Fatalf("Unexpected header %+v", stereo.WavHeader)
+	}
+	expected := [][]int{{1000, 0}, {7071, 7071}, {0, 0}, {7071, 0}, {0, 9071}}
+	for i := range expected {
+		for ch := range expected[i] {
+			if stereo.Data16[i][ch] != int16(expected[i][ch]) {
+				t.Fatalf("Sample %d: expected %v, got %v", i, expected[i], stereo.Data[i])
+			}
+		}
+	}
+
+	if _, err = ToStereoITU(testWav(48000, [][]int{{0}})); err == nil {
+		t.Fatal("Expected an error for mono input")
+	}
+}
+
+func TestToMonoSafe(t *testing.T) {
+	tone := sineData(440, 8000, 800, 0.5)
+	stereo := func(right func(v int) int) *Wav {
+		data := make([][]int, len(tone))
+		for i, sample := range tone {
+			data[i] = []int{sample[0], right(sample[0])}
+		}
+		return newWav(WavHeader{AudioFormat: 1, NumChannels: 2, SampleRate: 8000, BitsPerSample: 16}, data)
+	}
+
+	mono, err := ToMonoSafe(stereo(func(v int) int { return -v }))
+	if err != nil {
+		t.Fatalf("ToMonoSafe returned an error: %v", err)
+	}
+	if mono.NumChannels != 1 || mono.BlockAlign != 2 || len(mono.Data) != len(tone) {
+		t.Fatalf("Unexpected header %+v", mono.WavHeader)
+	}
+	if mono.RMS() < 0.3 {
+		t.Fatalf("Expected anti-phase stereo not to cancel, got RMS %v", mono.RMS())
+	}
+
+	mono, err = ToMonoSafe(stereo(func(v int) int { return v / 2 }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (tone[100][0] + tone[100][0]/2) / 2; math.Abs(float64(mono.Data[100][0]-expected)) > 1 {
+		t.Fatalf("Expected in-phase channels to be averaged to %d, got %d", expected, mono.Data[100][0])
+	}
+}
+
+func TestMapChannels(t *testing.T) {
+	w := testWav(8000, [][]int{{100, 100}, {-200, -200}, {300, 300}})
+
+	err := w.MapChannels(func(ch int, samples []float64) []float64 {
+		if ch == 0 {
+			for i := range samples {
+				samples[i] *= 2
+			}
+		}
+		return samples
+	})
+	if err != nil {
+		t.Fatalf("MapChannels returned an error: %v", err)
+	}
+	for i, expected := range []int{200, -400, 600} {
+		if w.Data[i][0] != expected || int(w.Data16[i][0]) != expected {
+			t.Fatalf("Sample %d of channel 0. Expected %d. Got %d", i, expected, w.Data[i][0])
+		}
+		if w.Data[i][1] != expected/2 {
+			t.Fatalf("Sample %d of channel 1 changed to %d", i, w.Data[i][1])
+		}
+	}
+
+	err = w.MapChannels(func(ch int, samples []float64) []float64 {
+		return samples[1:]
+	})
+	if err == nil {
+		t.Fatal("Expected an error when the channel length changes")
+	}
+}
+
+func TestPlanar(t *testing.T) {
+	w := testWav(8000, [][]int{{1, -1}, {2, -2}, {3, -3}})
+
+	planar := w.Planar()
+	expected := [][]int{{1, 2, 3}, {-1, -2, -3}}
+	if len(planar) != len(expected) {
+		t.Fatalf("Expected %d channels, got %d", len(expected), len(planar))
+	}
+	for ch := range expected {
+		for i := range expected[ch] {
+			if planar[ch][i] != expected[ch][i] {
+				t.Fatalf("planar[%d][%d]. Expected %d. Got %d", ch, i, expected[ch][i], planar[ch][i])
+			}
+		}
+	}
+
+	planar[0][0] = 100
+	if w.Data[0][0] != 1 {
+		t.Fatal("Modifying the planar copy changed Data")
+	}
+}
+
+func TestSampleAt(t *testing.T) {
+	w := testWav(8000, [][]int{{0, 1000}, {16384, 3000}, {-16384, 5000}})
+
+	for _, test := range []struct {
+		ch       int
+		pos      float64
+		expected float64
+	}{
+		{0, 0, 0},
+		{0, 0.5, 0.25},
+		{0, 1.25, 0.25},
+		{0, 2, -0.5},
+		{1, 0.5, 2000.0 / 32768},
+		{0, -1, 0},
+		{0, 10, -0.5},
+	} {
+		if v := w.SampleAt(test.ch, test.pos); v != test.expected {
+			t.Fatalf("SampleAt(%d, %v). Expected %v. Got %v", test.ch, test.pos, test.expected, v)
+		}
+	}
+}
+
+func TestXY(t *testing.T) {
+	w := testWav(8000, [][]int{{0, 16384}, {100, -16384}, {200, 0}})
+
+	xs, ys := w.XY(1)
+	if len(xs) != 3 || len(ys) != 3 {
+		t.Fatalf("Expected 3 points, got %d and %d", len(xs), len(ys))
+	}
+	for i := 1; i < len(xs); i++ {
+		if d := xs[i] - xs[i-1]; math.Abs(d-1.0/8000) > 1e-12 {
+			t.Fatalf("Expected points 1/8000 s apart, got %v", d)
+		}
+	}
+	if ys[0] != 0.5 || ys[1] != -0.5 || ys[2] != 0 {
+		t.Fatalf("Unexpected amplitudes %v", ys)
+	}
+}
+
+func TestMuteChannel(t *testing.T) {
+	for _, bits := range []uint16{8, 16} {
+		wav := newWav(WavHeader{AudioFormat: 1, NumChannels: 2, SampleRate: 8000, BitsPerSample: bits},
+			[][]int{{1, 2}, {3, 4}, {5, 6}})
+		if err := wav.MuteChannel(1); err != nil {
+			t.Fatalf("MuteChannel returned an error: %v", err)
+		}
+		silence := 0
+		if bits == 8 {
+			silence = 128
+		}
+		for i, sample := range wav.Data {
+			if sample[0] != 2*i+1 || sample[1] != silence {
+				t.Fatalf("%d bits: expected channel 0 intact and channel 1 silent, got %v", bits, wav.Data)
+			}
+		}
+		if bits == 8 && wav.Data8[2][1] != 128 || bits == 16 && wav.Data16[2][1] != 0 {
+			t.Fatalf("%d bits: expected the typed data to be muted too", bits)
+		}
+		if err := wav.MuteChannel(2); err == nil {
+			t.Fatal("Expected an error for an out of range channel")
+		}
+	}
+}
+
+func TestChannelLayout(t *testing.T) {
+	for _, test := range []struct {
+		fmt      []byte
+		expected []string
+	}{
+		{extensibleFmtChunk(6, 48000, 16, 16, 0x3f), []string{"FL", "FR", "FC", "LFE", "BL", "BR"}},
+		{extensibleFmtChunk(4, 48000, 16, 16, 0x603), []string{"FL", "FR", "SL", "SR"}},
+		{fmtChunk(6, 48000, 16), []string{"FL", "FR", "FC", "LFE", "BL", "BR"}},
+		{fmtChunk(2, 48000, 16), []string{"FL", "FR"}},
+		{fmtChunk(10, 48000, 16), make([]string, 10)},
+	} {
+		channels := int(bLEtoUint16(test.fmt, 10))
+		wav, err := ReadWav(bytes.NewReader(riff(test.fmt, chunk("data", make([]byte, 2*channels)))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if layout := wav.ChannelLayout(); !reflect.DeepEqual(layout, test.expected) {
+			t.Errorf("%d channels: expected %q, got %q", channels, test.expected, layout)
+		}
+	}
+}
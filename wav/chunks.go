@@ -0,0 +1,327 @@
+package wav
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+)
+
+// Instrument holds the sampler metadata of an 'inst' chunk.
+type Instrument struct {
+	UnshiftedNote uint8 // MIDI note of the recorded pitch
+	FineTune      int8  // pitch correction in cents
+	Gain          int8  // playback gain in dB
+	LowNote       uint8
+	HighNote      uint8
+	LowVelocity   uint8
+	HighVelocity  uint8
+}
+
+// PeakInfo is the peak of one channel as recorded in a 'PEAK' chunk.
+type PeakInfo struct {
+	Value    float32 // peak magnitude relative to full scale
+	Position uint32  // index of the sample holding the peak
+}
+
+// Sampler holds the sampler metadata and loop points of a 'smpl' chunk.
+type Sampler struct {
+	Manufacturer      uint32
+	Product           uint32
+	SamplePeriod      uint32 // nanoseconds per sample
+	MIDIUnityNote     uint32 // MIDI note of the recorded pitch
+	MIDIPitchFraction uint32 // fraction of a semitone above MIDIUnityNote
+	SMPTEFormat       uint32
+	SMPTEOffset       uint32
+	Loops             []Loop
+	SamplerData       []byte // manufacturer specific data following the loops
+}
+
+// Loop is a loop of a 'smpl' chunk. Start and End are the indices of the
+// first and last samples played in the loop.
+type Loop struct {
+	CuePointID uint32
+	Type       uint32 // 0 forward, 1 alternating, 2 backward
+	Start      uint32
+	End        uint32
+	Fraction   uint32 // fraction of a sample by which to extend End
+	PlayCount  uint32 // 0 for an endless loop
+}
+
+// RawChunk is a chunk ReadWav does not understand, kept so that Write can
+// emit it again.
+type RawChunk struct {
+	ID   string
+	Data []byte
+}
+
+// parseChunks walks the RIFF chunks of a complete wav file, setting up the
+// header from the chunks it understands, and returns the body of the data
+// chunk. A data chunk running past the end of b is cut short, leaving
+// ChunkSize as declared but NumSamples counting only the samples present.
+func (wav *Wav) parseChunks(b []byte) (data []byte, err error) {
+	if err = sniff(b); err != nil {
+		return nil, err
+	}
+	if len(b) < 12 {
+		return nil, newError(ErrTruncated, "wav: Invalid header size")
+	}
+	if string(b[0:4]) != "RIFF" {
+		return nil, newError(ErrNotRIFF, "wav: Header does not conatin 'RIFF'")
+	}
+	if string(b[8:12]) != "WAVE" {
+		return nil, newError(ErrNotRIFF, "wav: Header does not contain 'WAVE'")
+	}
+
+	wav.RIFFSize = bLEtoUint32(b, 4)
+
+	var haveFmt, haveData bool
+	for offset := 12; offset+4 <= len(b); {
+		id := string(b[offset : offset+4])
+		if offset+8 > len(b) {
+			if id == "data" {
+				return nil, newError(ErrTruncated, "wav: data chunk header runs past the end of the file")
+			}
+			// Ignore trailing bytes too short to be a chunk.
+			break
+		}
+		start := offset + 8
+		end := len(b)
+		// Compare as int64, since a size above 2 GiB overflows int on 32-bit
+		// platforms.
+		if size := int64(bLEtoUint32(b, offset+4)); size <= int64(len(b)-start) {
+			end = start + int(size)
+		} else {
+			if id != "data" {
+				return nil, newError(ErrTruncated, "wav: chunk '"+id+"' runs past the end of the file")
+			}
+			if start == len(b) {
+				return nil, newError(ErrTruncated, "wav: data chunk runs past the end of the file")
+			}
+		}
+		body := b[start:end]
+
+		if id == "data" {
+			data = body
+			wav.ChunkSize = bLEtoUint32(b, offset+4)
+			haveData = true
+		} else if known, err := wav.WavHeader.parseChunk(id, body); err != nil {
+			return nil, err
+		} else if !known {
+			wav.RawChunks = append(wav.RawChunks, RawChunk{id, append([]byte(nil), body...)})
+		}
+		haveFmt = haveFmt || id == "fmt "
+
+		// Chunks are word aligned.
+		offset = end + (end-start)%2
+	}
+
+	if !haveFmt {
+		return nil, errors.New("wav: Header does not contain 'fmt'")
+	}
+	if !haveData {
+		return nil, errors.New("wav: Header does not contain 'data'")
+	}
+	if err := wav.checkBlockAlign(); err != nil {
+		return nil, err
+	}
+
+	wav.NumSamples = len(data) / int(wav.BlockAlign)
+	wav.PartialBytes = len(data) % int(wav.BlockAlign)
+
+	return data, nil
+}
+
+// checkBlockAlign rejects headers whose BlockAlign cannot hold NumChannels
+// values. For PCM and float data each value must also sit in a container of
+// at most 8 bytes.
+func (wavHeader WavHeader) checkBlockAlign() error {
+	channels := wavHeader.NumChannels
+	if wavHeader.BlockAlign == 0 || channels == 0 || wavHeader.BlockAlign < channels {
+		return newError(ErrUnsupportedFormat, "wav: invalid block align")
+	}
+	if wavHeader.AudioFormat != formatPCM && wavHeader.AudioFormat != formatIEEEFloat {
+		return nil
+	}
+	if wavHeader.BlockAlign%channels != 0 || wavHeader.containerBytes() > 8 {
+		return newError(ErrUnsupportedFormat, "wav: invalid block align")
+	}
+	return nil
+}
+
+// parseChunk sets up the header from the body of a chunk other than 'data',
+// reporting whether the chunk is one it understands.
+func (wavHeader *WavHeader) parseChunk(id string, body []byte) (known bool, err error) {
+	switch id {
+	case "fmt ":
+		return true, wavHeader.setupWithFmtChunk(body)
+	case "inst":
+		if len(body) < 7 {
+			return true, errors.New("wav: 'inst' chunk is too short")
+		}
+		wavHeader.Instrument = &Instrument{
+			UnshiftedNote: body[0],
+			FineTune:      int8(body[1]),
+			Gain:          int8(body[2]),
+			LowNote:       body[3],
+			HighNote:      body[4],
+			LowVelocity:   body[5],
+			HighVelocity:  body[6],
+		}
+	case "smpl":
+		if len(body) < 36 {
+			return true, errors.New("wav: 'smpl' chunk is too short")
+		}
+		numLoops := int(bLEtoUint32(body, 28))
+		if numLoops > (len(body)-36)/24 {
+			return true, errors.New("wav: 'smpl' chunk is too short")
+		}
+		smpl := &Sampler{
+			Manufacturer:      bLEtoUint32(body, 0),
+			Product:           bLEtoUint32(body, 4),
+			SamplePeriod:      bLEtoUint32(body, 8),
+			MIDIUnityNote:     bLEtoUint32(body, 12),
+			MIDIPitchFraction: bLEtoUint32(body, 16),
+			SMPTEFormat:       bLEtoUint32(body, 20),
+			SMPTEOffset:       bLEtoUint32(body, 24),
+		}
+		for i := 0; i < numLoops; i++ {
+			p := 36 + 24*i
+			smpl.Loops = append(smpl.Loops, Loop{
+				CuePointID: bLEtoUint32(body, p),
+				Type:       bLEtoUint32(body, p+4),
+				Start:      bLEtoUint32(body, p+8),
+				End:        bLEtoUint32(body, p+12),
+				Fraction:   bLEtoUint32(body, p+16),
+				PlayCount:  bLEtoUint32(body, p+20),
+			})
+		}
+		// The sampler data follows the loops, cut short if the chunk is.
+		rest := body[36+24*numLoops:]
+		if size := bLEtoUint32(body, 32); size > 0 {
+			if int64(size) < int64(len(rest)) {
+				rest = rest[:size]
+			}
+			smpl.SamplerData = append([]byte(nil), rest...)
+		}
+		wavHeader.Sampler = smpl
+	case "PEAK":
+		if len(body) < 8 {
+			return true, errors.New("wav: 'PEAK' chunk is too short")
+		}
+		// The version and timestamp are followed by a value and position
+		// per channel.
+		wavHeader.Peaks = nil
+		for p := 8; p+8 <= len(body); p += 8 {
+			wavHeader.Peaks = append(wavHeader.Peaks, PeakInfo{
+				Value:    math.Float32frombits(bLEtoUint32(body, p)),
+				Position: bLEtoUint32(body, p+4),
+			})
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// readStreamHeader reads the chunks of a wav file from r up to and
+// including the header of the data chunk, setting up the header from them,
+// and leaves r at the first byte of data. Chunks are walked by their sizes,
+// so the bytes "data" appearing within an earlier chunk are not mistaken
+// for the data chunk.
+func (wavHeader *WavHeader) readStreamHeader(r io.Reader) error {
+	head := make([]byte, 12)
+	n, err := io.ReadFull(r, head)
+	if err := sniff(head[:n]); err != nil {
+		return err
+	}
+	if err != nil {
+		return newError(ErrTruncated, "wav: Invalid header size")
+	}
+	if string(head[0:4]) != "RIFF" {
+		return newError(ErrNotRIFF, "wav: Header does not conatin 'RIFF'")
+	}
+	if string(head[8:12]) != "WAVE" {
+		return newError(ErrNotRIFF, "wav: Header does not contain 'WAVE'")
+	}
+	wavHeader.RIFFSize = bLEtoUint32(head, 4)
+
+	var haveFmt bool
+	for {
+		if _, err = io.ReadFull(r, head[:8]); err == io.EOF {
+			return errors.New("wav: Header does not contain 'data'")
+		} else if err != nil {
+			return newError(ErrTruncated, "wav: chunk header runs past the end of the file")
+		}
+		id := string(head[0:4])
+		size := int64(bLEtoUint32(head, 4))
+
+		if id == "data" {
+			if !haveFmt {
+				return errors.New("wav: Header does not contain 'fmt'")
+			}
+			if err := wavHeader.checkBlockAlign(); err != nil {
+				return err
+			}
+			if size > math.MaxInt {
+				return newError(ErrTooLarge, "wav: data chunk is too large to decode in memory")
+			}
+			wavHeader.ChunkSize = uint32(size)
+			wavHeader.NumSamples = int(size / int64(wavHeader.BlockAlign))
+			wavHeader.PartialBytes = int(size % int64(wavHeader.BlockAlign))
+			return nil
+		}
+
+		// Chunks are word aligned.
+		padded := size + size%2
+		switch id {
+		case "fmt ", "inst", "smpl", "PEAK":
+			body, err := ioutil.ReadAll(io.LimitReader(r, padded))
+			if err != nil {
+				return err
+			}
+			if int64(len(body)) < size {
+				return newError(ErrTruncated, "wav: chunk '"+id+"' runs past the end of the file")
+			}
+			if _, err = wavHeader.parseChunk(id, body[:size]); err != nil {
+				return err
+			}
+			haveFmt = haveFmt || id == "fmt "
+		default:
+			if n, err := io.CopyN(ioutil.Discard, r, padded); err != nil && n < size {
+				return newError(ErrTruncated, "wav: chunk '"+id+"' runs past the end of the file")
+			}
+		}
+	}
+}
+
+// setupWithFmtChunk sets up the format fields of the header from the body of
+// a 'fmt ' chunk.
+func (wavHeader *WavHeader) setupWithFmtChunk(body []byte) error {
+	if len(body) < 16 {
+		return errors.New("wav: 'fmt ' chunk is too short")
+	}
+
+	wavHeader.AudioFormat = bLEtoUint16(body, 0)
+	wavHeader.NumChannels = bLEtoUint16(body, 2)
+	wavHeader.SampleRate = bLEtoUint32(body, 4)
+	wavHeader.ByteRate = bLEtoUint32(body, 8)
+	wavHeader.BlockAlign = bLEtoUint16(body, 12)
+	wavHeader.BitsPerSample = bLEtoUint16(body, 14)
+
+	// WAVE_FORMAT_EXTENSIBLE carries the valid bits and, as the first two
+	// bytes of the sub-format GUID, the actual format. Some writers declare
+	// the extension in cbSize without including it; the extension is only
+	// read from bytes the chunk holds, and taken to be PCM when missing.
+	if wavHeader.AudioFormat == formatExtensible {
+		if len(body) >= 40 {
+			wavHeader.ValidBitsPerSample = bLEtoUint16(body, 18)
+			wavHeader.ChannelMask = bLEtoUint32(body, 20)
+			wavHeader.AudioFormat = bLEtoUint16(body, 24)
+		} else {
+			wavHeader.AudioFormat = formatPCM
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package wav
+
+import (
+	"errors"
+	"sync"
+)
+
+// Codec decodes the data of a non-PCM audio format. Decode is called with
+// each BlockAlign sized block of the data chunk in turn (the last may be
+// shorter) and returns the interleaved samples it holds, scaled for the
+// header's BitsPerSample.
+type Codec interface {
+	Decode(block []byte, header WavHeader) ([]int, error)
+}
+
+// Encoder encodes samples in a non-PCM audio format. Encode is called once
+// with all the interleaved samples of a Wav and returns the body of its data
+// chunk.
+type Encoder interface {
+	Encode(samples []int, header WavHeader) ([]byte, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = make(map[uint16]Codec)
+	encoders = make(map[uint16]Encoder)
+)
+
+// RegisterCodec makes ReadWav decode files with AudioFormat format using c.
+// Registering a nil Codec removes the registration.
+func RegisterCodec(format uint16, c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if c == nil {
+		delete(codecs, format)
+		return
+	}
+	codecs[format] = c
+}
+
+// lookupCodec returns the Codec registered for format, if any.
+func lookupCodec(format uint16) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[format]
+	return c, ok
+}
+
+// RegisterEncoder makes Write encode Wavs with AudioFormat format using e.
+// Registering a nil Encoder removes the registration.
+func RegisterEncoder(format uint16, e Encoder) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if e == nil {
+		delete(encoders, format)
+		return
+	}
+	encoders[format] = e
+}
+
+// lookupEncoder returns the Encoder registered for format, if any.
+func lookupEncoder(format uint16) (Encoder, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	e, ok := encoders[format]
+	return e, ok
+}
+
+// decodeWithCodec decodes data block by block with c and populates Data.
+func (wav *Wav) decodeWithCodec(c Codec, data []byte) error {
+	if wav.BlockAlign == 0 || wav.NumChannels == 0 {
+		return errors.New("wav: invalid format for codec")
+	}
+
+	var interleaved []int
+	for len(data) > 0 {
+		block := data
+		if len(block) > int(wav.BlockAlign) {
+			block = block[:wav.BlockAlign]
+		}
+		data = data[len(block):]
+
+		samples, err := c.Decode(block, wav.WavHeader)
+		if err != nil {
+			return err
+		}
+		interleaved = append(interleaved, samples...)
+	}
+
+	channels := int(wav.NumChannels)
+	if len(interleaved)%channels != 0 {
+		return errors.New("wav: codec returned a partial sample")
+	}
+	wav.NumSamples = len(interleaved) / channels
+	wav.Data = make([][]int, wav.NumSamples)
+	for i := range wav.Data {
+		wav.Data[i] = interleaved[i*channels : (i+1)*channels : (i+1)*channels]
+	}
+	wav.fillTyped()
+
+	return nil
+}
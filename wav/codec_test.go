@@ -0,0 +1,98 @@
+package wav
+
+import (
+	"bytes"
+	"testing"
+)
+
+const testFormat = 0x7fff
+
+// byteCodec stores the top byte of each 16-bit sample, unsigned.
+type byteCodec struct{}
+
+func (byteCodec) Encode(samples []int, header WavHeader) ([]byte, error) {
+	block := make([]byte, len(samples))
+	for i, s := range samples {
+		block[i] = byte(s>>8 + 128)
+	}
+	return block, nil
+}
+
+func (byteCodec) Decode(block []byte, header WavHeader) ([]int, error) {
+	samples := make([]int, len(block))
+	for i, b := range block {
+		samples[i] = (int(b) - 128) << 8
+	}
+	return samples, nil
+}
+
+func TestRegisterCodec(t *testing.T) {
+	var format bytes.Buffer
+	write(&format, uint16(testFormat))
+	write(&format, uint16(2))
+	write(&format, uint32(8000))
+	write(&format, uint32(16000))
+	write(&format, uint16(2))
+	write(&format, uint16(16))
+	file := riff(chunk("fmt ", format.Bytes()), chunk("data", []byte{128, 129, 127, 255}))
+
+	RegisterCodec(testFormat, byteCodec{})
+	defer RegisterCodec(testFormat, nil)
+
+	wav, err := ReadWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadWav returned an error: %v", err)
+	}
+	if wav.AudioFormat != testFormat || wav.NumSamples != 2 {
+		t.Fatalf("Unexpected header: %+v", wav.WavHeader)
+	}
+	expected := [][]int{{0, 256}, {-256, 127 << 8}}
+	for i := range expected {
+		for ch := range expected[i] {
+			if wav.Data[i][ch] != expected[i][ch] || int(wav.Data16[i][ch]) != expected[i][ch] {
+				t.Fatalf("Sample %d channel %d. Expected %d. Got %d", i, ch, expected[i][ch], wav.Data[i][ch])
+			}
+		}
+	}
+
+	// Samples do not share spare capacity.
+	_ = append(wav.Data[0], 1)
+	if wav.Data[1][0] != -256 {
+		t.Fatalf("Appending to sample 0 changed sample 1 to %v", wav.Data[1])
+	}
+
+	// The block layout of a codec format is not PCM's.
+	wav.Recompute()
+	if wav.BlockAlign != 2 || wav.ByteRate != 16000 {
+		t.Fatalf("Expected Recompute to keep the codec block layout, got %+v", wav.WavHeader)
+	}
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	wav := testWav(8000, [][]int{{0, 256}, {-256, 127 << 8}, {-32768, 512}})
+	wav.AudioFormat = testFormat
+
+	var buf bytes.Buffer
+	if err := wav.Write(&buf); err == nil {
+		t.Fatal("Expected an error writing a format with no encoder")
+	}
+
+	RegisterEncoder(testFormat, byteCodec{})
+	defer RegisterEncoder(testFormat, nil)
+	RegisterCodec(testFormat, byteCodec{})
+	defer RegisterCodec(testFormat, nil)
+
+	buf.Reset()
+	if err := wav.Write(&buf); err != nil {
+		t.Fatalf("Write returned an error: %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte{'d', 'a', 't', 'a', 6, 0, 0, 0, 128, 129, 127, 255, 0, 130}) {
+		t.Fatal("Expected the data chunk to be written by the encoder")
+	}
+
+	reread, err := ReadWav(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareWavs(t, wav, reread)
+}
@@ -0,0 +1,38 @@
+package wav
+
+import (
+	"github.com/mjibson/go-dsp/dsputils"
+	"github.com/mjibson/go-dsp/fft"
+)
+
+// Convolve returns the linear convolution of signal and impulse, of length
+// len(signal)+len(impulse)-1, computed blockwise by FFT overlap-add. This
+// makes it practical to apply long impulse responses such as reverbs.
+func Convolve(signal, impulse []float64) []float64 {
+	if len(signal) == 0 || len(impulse) == 0 {
+		return nil
+	}
+
+	size := dsputils.NextPowerOf2(2 * len(impulse))
+	block := size - len(impulse) + 1
+	h := fft.FFT(dsputils.ZeroPad(dsputils.ToComplex(impulse), size))
+
+	y := make([]float64, len(signal)+len(impulse)-1)
+	for offset := 0; offset < len(signal); offset += block {
+		end := offset + block
+		if end > len(signal) {
+			end = len(signal)
+		}
+
+		x := fft.FFT(dsputils.ZeroPad(dsputils.ToComplex(signal[offset:end]), size))
+		for i := range x {
+			x[i] *= h[i]
+		}
+		x = fft.IFFT(x)
+
+		for i := 0; i < end-offset+len(impulse)-1; i++ {
+			y[offset+i] += real(x[i])
+		}
+	}
+	return y
+}
@@ -0,0 +1,35 @@
+package wav
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mjibson/go-dsp/dsputils"
+)
+
+func TestConvolve(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	signal := make([]float64, 1000)
+	for i := range signal {
+		signal[i] = r.Float64()*2 - 1
+	}
+
+	y := Convolve(signal, []float64{1})
+	if !dsputils.PrettyClose(y, signal) {
+		t.Fatal("Convolving with a unit impulse should return the input")
+	}
+
+	impulse := make([]float64, 37)
+	for i := range impulse {
+		impulse[i] = r.Float64()*2 - 1
+	}
+	expected := make([]float64, len(signal)+len(impulse)-1)
+	for i, s := range signal {
+		for j, h := range impulse {
+			expected[i+j] += s * h
+		}
+	}
+	if y = Convolve(signal, impulse); !dsputils.PrettyClose(y, expected) {
+		t.Fatal("Convolve does not match direct convolution")
+	}
+}
@@ -0,0 +1,74 @@
+package wav
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WriteCSV writes the samples of w to out as CSV, one row per sample with a
+// column per channel holding its integer value.
+func (w *Wav) WriteCSV(out io.Writer) error {
+	cw := csv.NewWriter(out)
+	row := make([]string, w.NumChannels)
+	for _, sample := range w.Data {
+		for ch, v := range sample {
+			row[ch] = strconv.Itoa(v)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV reads samples written as by WriteCSV, one row per sample with a
+// column per channel, into a PCM Wav of the given format. Values may have a
+// fractional part, which is rounded off, but must be in the range of bits;
+// 8-bit values are unsigned as in the file format.
+func ReadCSV(r io.Reader, sampleRate uint32, bits uint16) (*Wav, error) {
+	if bits != 8 && bits != 16 && bits != 24 && bits != 32 {
+		return nil, newError(ErrUnsupportedFormat, "wav: unsupported bits per sample")
+	}
+	lo, hi := -math.Ldexp(1, int(bits)-1), math.Ldexp(1, int(bits)-1)-1
+	if bits == 8 {
+		lo, hi = 0, 255
+	}
+
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("wav: no samples in CSV")
+	}
+
+	data := make([][]int, len(rows))
+	for i, row := range rows {
+		data[i] = make([]int, len(row))
+		for ch, field := range row {
+			v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, fmt.Errorf("wav: CSV row %d: %v", i+1, err)
+			}
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				return nil, fmt.Errorf("wav: CSV row %d: %v is not a sample value", i+1, v)
+			}
+			v = math.Round(v)
+			if v < lo || v > hi {
+				return nil, fmt.Errorf("wav: CSV row %d: %v out of range for %d bits", i+1, v, bits)
+			}
+			data[i][ch] = int(v)
+		}
+	}
+
+	header := WavHeader{AudioFormat: formatPCM, NumChannels: uint16(len(rows[0])), SampleRate: sampleRate, BitsPerSample: bits}
+	return newWav(header, data), nil
+}
@@ -0,0 +1,57 @@
+package wav
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	wav := newWav(WavHeader{AudioFormat: 1, NumChannels: 2, SampleRate: 8000, BitsPerSample: 16},
+		[][]int{{1, -1}, {-32768, 32767}, {0, 1234}})
+	var buf bytes.Buffer
+	if err := wav.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV returned an error: %v", err)
+	}
+
+	rows := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 rows, got %d: %q", len(rows), buf.String())
+	}
+	for i, row := range rows {
+		if columns := strings.Split(row, ","); len(columns) != 2 {
+			t.Fatalf("Row %d: expected 2 columns, got %q", i, row)
+		}
+	}
+	if rows[1] != "-32768,32767" {
+		t.Fatalf("Expected row 1 to be %q, got %q", "-32768,32767", rows[1])
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	wav := newWav(WavHeader{AudioFormat: 1, NumChannels: 2, SampleRate: 8000, BitsPerSample: 16},
+		[][]int{{1, -1}, {-32768, 32767}, {0, 1234}})
+	var buf bytes.Buffer
+	if err := wav.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	reread, err := ReadCSV(&buf, 8000, 16)
+	if err != nil {
+		t.Fatalf("ReadCSV returned an error: %v", err)
+	}
+	compareWavs(t, wav, reread)
+
+	mono, err := ReadCSV(strings.NewReader("0.4\n 1.5\n-2.6\n"), 8000, 16)
+	if err != nil {
+		t.Fatalf("ReadCSV returned an error: %v", err)
+	}
+	if mono.NumChannels != 1 || mono.Data[0][0] != 0 || mono.Data[1][0] != 2 || mono.Data[2][0] != -3 {
+		t.Fatalf("Expected fractional values to be rounded, got %v", mono.Data)
+	}
+
+	for _, input := range []string{"", "1,2\n3\n", "abc\n", "32768\n", "NaN\n", "1,nan\n", "Inf\n", "-Inf\n"} {
+		if _, err = ReadCSV(strings.NewReader(input), 8000, 16); err == nil {
+			t.Errorf("Expected an error reading %q", input)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package wav
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"github.com/mjibson/go-dsp/fft"
+	"github.com/mjibson/go-dsp/window"
+)
+
+const (
+	// denoiseFrame is the FFT size used by the spectral subtraction denoiser.
+	// Noise profiles hold denoiseFrame/2+1 magnitudes.
+	denoiseFrame = 1024
+
+	// spectralFloor is the fraction of the original magnitude kept in bins
+	// where the noise estimate exceeds the signal, which avoids the "musical
+	// noise" of bins switching fully on and off.
+	spectralFloor = 0.05
+)
+
+// EstimateNoiseProfile returns the average magnitude spectrum of the mono
+// signal of w between samples start and end, which should contain only
+// noise. It returns nil if the range is empty or out of bounds.
+func EstimateNoiseProfile(w *Wav, start, end int) []float64 {
+	if start < 0 || end > len(w.Data) || start >= end {
+		return nil
+	}
+
+	x := w.mono()[start:end]
+	win := window.Hann(denoiseFrame)
+	profile := make([]float64, denoiseFrame/2+1)
+	frames := 0
+	for offset := 0; offset == 0 || offset+denoiseFrame <= len(x); offset += denoiseFrame / 2 {
+		frame := make([]float64, denoiseFrame)
+		copy(frame, x[offset:])
+		for i := range frame {
+			frame[i] *= win[i]
+		}
+		for k, v := range fft.FFTReal(frame)[:len(profile)] {
+			profile[k] += cmplx.Abs(v)
+		}
+		frames++
+	}
+	for k := range profile {
+		profile[k] /= float64(frames)
+	}
+	return profile
+}
+
+// SpectralSubtract returns a copy of w with the magnitude spectrum
+// noiseProfile, as returned by EstimateNoiseProfile, subtracted from every
+// frame of every channel. Frames overlap by half and are resynthesized with
+// their original phase.
+func SpectralSubtract(w *Wav, noiseProfile []float64) (*Wav, error) {
+	if len(noiseProfile) != denoiseFrame/2+1 {
+		return nil, errors.New("wav: noise profile has the wrong length")
+	}
+
+	data := make([][]int, len(w.Data))
+	for i := range data {
+		data[i] = make([]int, w.NumChannels)
+	}
+	out := newWav(w.WavHeader, data)
+
+	const hop = denoiseFrame / 2
+	win := window.Hann(denoiseFrame)
+	for ch := 0; ch < int(w.NumChannels); ch++ {
+		x := w.channel(ch)
+		y := make([]float64, len(x)+denoiseFrame)
+		norm := make([]float64, len(y))
+
+		for offset := -hop; offset < len(x); offset += hop {
+			frame := make([]complex128, denoiseFrame)
+			for i := range frame {
+				if j := offset + i; j >= 0 && j < len(x) {
+					frame[i] = complex(x[j]*win[i], 0)
+				}
+			}
+
+			X := fft.FFT(frame)
+			for k := range X {
+				bin := k
+				if bin > denoiseFrame/2 {
+					bin = denoiseFrame - k
+				}
+				mag := cmplx.Abs(X[k])
+				clean := math.Max(mag-noiseProfile[bin], spectralFloor*mag)
+				if mag > 0 {
+					X[k] *= complex(clean/mag, 0)
+				}
+			}
+
+			for i, v := range fft.IFFT(X) {
+				if j := offset + i; j >= 0 && j < len(y) {
+					y[j] += real(v) * win[i]
+					norm[j] += win[i] * win[i]
+				}
+			}
+		}
+
+		for i := range x {
+			if norm[i] > 1e-9 {
+				y[i] /= norm[i]
+			}
+		}
+		out.setChannel(ch, y[:len(x)])
+	}
+
+	return out, nil
+}
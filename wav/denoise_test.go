@@ -0,0 +1,56 @@
+package wav
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// snr returns the signal to noise ratio in dB of x against the reference.
+func snr(reference, x []float64) float64 {
+	var signal, noise float64
+	for i := range reference {
+		signal += reference[i] * reference[i]
+		noise += (x[i] - reference[i]) * (x[i] - reference[i])
+	}
+	return 10 * math.Log10(signal/noise)
+}
+
+func TestSpectralSubtract(t *testing.T) {
+	const sampleRate = 16000
+	r := rand.New(rand.NewSource(1))
+	clean := make([]float64, 3*sampleRate)
+	noisy := make([][]int, len(clean))
+	for i := range clean {
+		if i >= sampleRate {
+			clean[i] = 0.3 * math.Sin(2*math.Pi*440*float64(i)/sampleRate)
+		}
+		noisy[i] = []int{int(32767 * (clean[i] + 0.05*(r.Float64()*2-1)))}
+	}
+	w := testWav(sampleRate, noisy)
+
+	profile := EstimateNoiseProfile(w, 0, sampleRate)
+	if len(profile) != denoiseFrame/2+1 {
+		t.Fatalf("Expected a profile of %d bins, got %d", denoiseFrame/2+1, len(profile))
+	}
+	denoised, err := SpectralSubtract(w, profile)
+	if err != nil {
+		t.Fatalf("SpectralSubtract returned an error: %v", err)
+	}
+	if denoised.NumSamples != w.NumSamples {
+		t.Fatalf("Expected %d samples, got %d", w.NumSamples, denoised.NumSamples)
+	}
+
+	before := snr(clean[sampleRate:], w.channel(0)[sampleRate:])
+	after := snr(clean[sampleRate:], denoised.channel(0)[sampleRate:])
+	if after < before+3 {
+		t.Fatalf("Expected denoising to raise the SNR by at least 3 dB. Before %v dB. After %v dB", before, after)
+	}
+
+	if _, err = SpectralSubtract(w, profile[1:]); err == nil {
+		t.Fatal("Expected an error for a profile of the wrong length")
+	}
+	if EstimateNoiseProfile(w, 10, 5) != nil {
+		t.Fatal("Expected a nil profile for an empty range")
+	}
+}
@@ -0,0 +1,50 @@
+package wav
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"github.com/mjibson/go-dsp/dsputils"
+	"github.com/mjibson/go-dsp/fft"
+	"github.com/mjibson/go-dsp/window"
+)
+
+// thdBins is the number of bins on each side of a harmonic whose power is
+// counted as part of it, covering the spread of the Hann window.
+const thdBins = 3
+
+// THD returns the total harmonic distortion of the mono mix of w, a tone at
+// fundamental Hz: the ratio of the RMS amplitude of its harmonics below the
+// Nyquist frequency to that of the fundamental. A pure sine gives about 0.
+func THD(w *Wav, fundamental float64) (float64, error) {
+	if fundamental <= 0 || fundamental >= float64(w.SampleRate)/2 {
+		return 0, errors.New("wav: fundamental out of range")
+	}
+	if len(w.Data) == 0 {
+		return 0, errors.New("wav: no data to measure distortion of")
+	}
+
+	x := w.mono()
+	window.Apply(x, window.Hann)
+	spectrum := fft.FFTReal(dsputils.ZeroPadToPow2(x))
+	binWidth := float64(w.SampleRate) / float64(len(spectrum))
+	power := func(freq float64) float64 {
+		k := int(math.Round(freq / binWidth))
+		var p float64
+		for i := max(1, k-thdBins); i <= min(len(spectrum)/2, k+thdBins); i++ {
+			p += math.Pow(cmplx.Abs(spectrum[i]), 2)
+		}
+		return p
+	}
+
+	fund := power(fundamental)
+	if fund == 0 {
+		return 0, errors.New("wav: no energy at the fundamental")
+	}
+	var harmonics float64
+	for f := 2 * fundamental; f < float64(w.SampleRate)/2; f += fundamental {
+		harmonics += power(f)
+	}
+	return math.Sqrt(harmonics / fund), nil
+}
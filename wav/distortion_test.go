@@ -0,0 +1,33 @@
+package wav
+
+import (
+	"testing"
+)
+
+func TestTHD(t *testing.T) {
+	clean := testWav(44100, sineData(1000, 44100, 44100, 0.5))
+	thd, err := THD(clean, 1000)
+	if err != nil {
+		t.Fatalf("THD returned an error: %v", err)
+	}
+	if thd > 0.001 {
+		t.Errorf("Expected near zero THD for a clean sine, got %v", thd)
+	}
+
+	// Hard clipping at half the peak adds odd harmonics.
+	clipped := testWav(44100, sineData(1000, 44100, 44100, 1))
+	for i, sample := range clipped.Data {
+		clipped.set(i, 0, max(-16384, min(16384, sample[0])))
+	}
+	clippedTHD, err := THD(clipped, 1000)
+	if err != nil {
+		t.Fatalf("THD returned an error: %v", err)
+	}
+	if clippedTHD < 0.1 {
+		t.Errorf("Expected a clipped sine to have high THD, got %v", clippedTHD)
+	}
+
+	if _, err = THD(clean, 30000); err == nil {
+		t.Error("Expected an error for a fundamental above the Nyquist frequency")
+	}
+}
@@ -0,0 +1,116 @@
+package wav
+
+import (
+	"errors"
+	"math"
+
+	"github.com/mjibson/go-dsp/spectral"
+	"github.com/mjibson/go-dsp/window"
+)
+
+var (
+	dtmfLow  = [4]float64{697, 770, 852, 941}
+	dtmfHigh = [4]float64{1209, 1336, 1477, 1633}
+	dtmfKeys = [4][4]byte{
+		{'1', '2', '3', 'A'},
+		{'4', '5', '6', 'B'},
+		{'7', '8', '9', 'C'},
+		{'*', '0', '#', 'D'},
+	}
+)
+
+const (
+	// dtmfFrame is the length of the frames DecodeDTMF analyses. Frames
+	// start half a frame apart, and a digit must be heard in two frames in a
+	// row, so tones of the standard minimum of 40ms are caught.
+	dtmfFrame = 0.025
+
+	// dtmfMinLevel is the minimum amplitude of each tone, relative to full
+	// scale.
+	dtmfMinLevel = 0.01
+
+	// dtmfPurity is the minimum fraction of the power of a frame that the
+	// two tones of a digit must account for.
+	dtmfPurity = 0.5
+)
+
+// DecodeDTMF returns the touch-tone digits dialled in the mono mix of w, in
+// order. A digit held down is reported once; the same digit pressed again
+// after a pause is reported again.
+func DecodeDTMF(w *Wav) (string, error) {
+	n := int(dtmfFrame * float64(w.SampleRate))
+	if n < 1 {
+		return "", errors.New("wav: sample rate too low to decode DTMF")
+	}
+	if float64(w.SampleRate)/2 <= dtmfHigh[3] {
+		return "", errors.New("wav: sample rate too low to decode DTMF")
+	}
+
+	x := w.mono()
+	win := window.Hamming(n)
+	var gain float64
+	for _, v := range win {
+		gain += v
+	}
+
+	var digits []byte
+	var current byte
+	var count int
+	frame := make([]float64, n)
+	for offset := 0; offset+n <= len(x); offset += n / 2 {
+		var power float64
+		for i := range frame {
+			power += x[offset+i] * x[offset+i]
+			frame[i] = x[offset+i] * win[i]
+		}
+		power /= float64(n)
+
+		key := dtmfKey(frame, gain, power, float64(w.SampleRate))
+		if key == 0 || key != current {
+			current, count = key, 0
+		}
+		if key != 0 {
+			count++
+			if count == 2 {
+				digits = append(digits, key)
+			}
+		}
+	}
+	return string(digits), nil
+}
+
+// dtmfKey returns the key whose tones frame, windowed with a window summing
+// to gain, holds, or 0 if it holds none. power is the mean square of the
+// frame before windowing.
+func dtmfKey(frame []float64, gain, power, sampleRate float64) byte {
+	// amplitude estimates the amplitude of a sine at freq in frame.
+	amplitude := func(freq float64) float64 {
+		return 2 * math.Sqrt(spectral.Goertzel(frame, freq, sampleRate)) / gain
+	}
+	strongest := func(freqs [4]float64) (int, float64) {
+		best, a := 0, make([]float64, len(freqs))
+		for i, f := range freqs {
+			a[i] = amplitude(f)
+			if a[i] > a[best] {
+				best = i
+			}
+		}
+		// The strongest tone must stand well clear of the others.
+		for i := range a {
+			if i != best && a[i] > a[best]/2 {
+				return -1, 0
+			}
+		}
+		return best, a[best]
+	}
+
+	row, low := strongest(dtmfLow)
+	col, high := strongest(dtmfHigh)
+	if row < 0 || col < 0 || low < dtmfMinLevel || high < dtmfMinLevel {
+		return 0
+	}
+	if (low*low+high*high)/2 < dtmfPurity*power {
+		return 0
+	}
+	return dtmfKeys[row][col]
+}
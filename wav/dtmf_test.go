@@ -0,0 +1,57 @@
+package wav
+
+import (
+	"math"
+	"testing"
+)
+
+// dtmfData returns the digits of number as 50ms tones separated by 50ms of
+// silence.
+func dtmfData(number string, sampleRate uint32) [][]int {
+	var data [][]int
+	n := int(sampleRate) / 20
+	for _, key := range []byte(number) {
+		var low, high float64
+		for row := range dtmfKeys {
+			for col := range dtmfKeys[row] {
+				if dtmfKeys[row][col] == key {
+					low, high = dtmfLow[row], dtmfHigh[col]
+				}
+			}
+		}
+		for i := 0; i < n; i++ {
+			t := float64(i) / float64(sampleRate)
+			v := 0.3*math.Sin(2*math.Pi*low*t) + 0.3*math.Sin(2*math.Pi*high*t)
+			data = append(data, []int{int(v * 32767)})
+		}
+		for i := 0; i < n; i++ {
+			data = append(data, []int{0})
+		}
+	}
+	return data
+}
+
+func TestDecodeDTMF(t *testing.T) {
+	for _, test := range []struct {
+		number     string
+		sampleRate uint32
+	}{
+		{"1234", 8000},
+		{"5#0*D", 8000},
+		{"9988", 44100},
+	} {
+		digits, err := DecodeDTMF(testWav(test.sampleRate, dtmfData(test.number, test.sampleRate)))
+		if err != nil {
+			t.Fatalf("DecodeDTMF returned an error: %v", err)
+		}
+		if digits != test.number {
+			t.Errorf("Expected %q at %d Hz, got %q", test.number, test.sampleRate, digits)
+		}
+	}
+
+	// A single tone is not a digit.
+	digits, err := DecodeDTMF(testWav(8000, sineData(697, 8000, 8000, 0.5)))
+	if err != nil || digits != "" {
+		t.Errorf("Expected no digits from a single tone, got %q (%v)", digits, err)
+	}
+}
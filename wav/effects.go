@@ -0,0 +1,231 @@
+package wav
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// timeConstant returns the one-pole smoothing coefficient for a time
+// constant of seconds at sampleRate.
+func timeConstant(seconds float64, sampleRate uint32) float64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return math.Exp(-1 / (seconds * float64(sampleRate)))
+}
+
+// NoiseGate silences w while its level is below thresholdDB, relative to
+// full scale. The gate opens over attack seconds and closes over release
+// seconds. All channels are gated together.
+func (w *Wav) NoiseGate(thresholdDB, attack, release float64) {
+	threshold := math.Pow(10, thresholdDB/20)
+	attackCoef := timeConstant(attack, w.SampleRate)
+	releaseCoef := timeConstant(release, w.SampleRate)
+
+	x := make([][]float64, w.NumChannels)
+	for ch := range x {
+		x[ch] = w.channel(ch)
+	}
+
+	var env, gain float64
+	for i := range w.Data {
+		var peak float64
+		for ch := range x {
+			peak = math.Max(peak, math.Abs(x[ch][i]))
+		}
+		env = math.Max(peak, env*releaseCoef)
+
+		if env >= threshold {
+			gain = 1 - (1-gain)*attackCoef
+		} else {
+			gain *= releaseCoef
+		}
+		for ch := range x {
+			x[ch][i] *= gain
+		}
+	}
+
+	for ch := range x {
+		w.setChannel(ch, x[ch])
+	}
+}
+
+// Compress reduces the dynamic range of w. Whenever the level exceeds
+// thresholdDB, relative to full scale, the excess is divided by ratio; an
+// infinite ratio makes it a limiter. Gain reduction is applied over attack
+// seconds and recovers over release seconds. All channels share the same
+// gain so the stereo image is kept.
+func (w *Wav) Compress(thresholdDB, ratio, attack, release float64) {
+	if ratio < 1 {
+		ratio = 1
+	}
+	attackCoef := timeConstant(attack, w.SampleRate)
+	releaseCoef := timeConstant(release, w.SampleRate)
+
+	x := make([][]float64, w.NumChannels)
+	for ch := range x {
+		x[ch] = w.channel(ch)
+	}
+
+	// reduction is the current gain reduction in dB.
+	var reduction float64
+	for i := range w.Data {
+		var peak float64
+		for ch := range x {
+			peak = math.Max(peak, math.Abs(x[ch][i]))
+		}
+
+		var target float64
+		if level := 20 * math.Log10(peak); level > thresholdDB {
+			target = (level - thresholdDB) * (1 - 1/ratio)
+		}
+		if target > reduction {
+			reduction = target + (reduction-target)*attackCoef
+		} else {
+			reduction = target + (reduction-target)*releaseCoef
+		}
+
+		gain := math.Pow(10, -reduction/20)
+		for ch := range x {
+			x[ch][i] *= gain
+		}
+	}
+
+	for ch := range x {
+		w.setChannel(ch, x[ch])
+	}
+}
+
+// Pan moves a stereo image to position, from -1 (hard left) through 0
+// (centre) to 1 (hard right). The channels are scaled by the cosine and sine
+// of a quarter turn, a constant-power law, so that the total power stays the
+// same at every position and the centre is 3 dB down on each channel. A mono
+// Wav is first upmixed to stereo.
+func (w *Wav) Pan(position float64) error {
+	if position < -1 || position > 1 {
+		return errors.New("wav: pan position out of range")
+	}
+	if w.NumChannels == 1 {
+		data := make([][]int, len(w.Data))
+		for i, sample := range w.Data {
+			data[i] = []int{sample[0], sample[0]}
+		}
+		header := w.WavHeader
+		header.NumChannels = 2
+		header.ChannelMask = 0
+		stereo := newWav(header, data)
+		stereo.RawChunks = w.RawChunks
+		*w = *stereo
+	}
+	if w.NumChannels != 2 {
+		return errors.New("wav: can only pan mono or stereo")
+	}
+
+	theta := (position + 1) * math.Pi / 4
+	for ch, gain := range []float64{math.Cos(theta), math.Sin(theta)} {
+		x := w.channel(ch)
+		for i := range x {
+			x[i] *= gain
+		}
+		w.setChannel(ch, x)
+	}
+	return nil
+}
+
+// Tremolo modulates the amplitude of w with a sine of rate Hz. depth, from 0
+// to 1, is how far the gain dips: at depth 1 the signal is periodically
+// silenced, at depth 0 it is unchanged.
+func (w *Wav) Tremolo(rate, depth float64) {
+	depth = math.Max(0, math.Min(1, depth))
+	w.applyGain(func(i int) float64 {
+		t := float64(i) / float64(w.SampleRate)
+		return 1 - depth*(1+math.Sin(2*math.Pi*rate*t))/2
+	})
+}
+
+// ApplyGainEnvelope multiplies each sample of w by the gain at the same
+// position in env, such as an automation curve. An env of a different length
+// than w is stretched to fit, interpolating linearly between its values, so
+// that its first and last values apply to the first and last samples.
+func (w *Wav) ApplyGainEnvelope(env []float64) error {
+	if len(env) == 0 {
+		return errors.New("wav: empty gain envelope")
+	}
+	step := 0.0
+	if len(w.Data) > 1 {
+		step = float64(len(env)-1) / float64(len(w.Data)-1)
+	}
+	w.applyGain(func(i int) float64 {
+		return interpolate(env, float64(i)*step, step, Linear)
+	})
+	return nil
+}
+
+// applyGain multiplies every channel of sample i by gain(i).
+func (w *Wav) applyGain(gain func(i int) float64) {
+	for ch := 0; ch < int(w.NumChannels); ch++ {
+		x := w.channel(ch)
+		for i := range x {
+			x[i] *= gain(i)
+		}
+		w.setChannel(ch, x)
+	}
+}
+
+// Echo adds repeats of w delayed by delay. Each repeat is feedback times the
+// previous one, and mix sets the level of the repeats against the original.
+// The length of w is unchanged, so repeats past its end are lost.
+func (w *Wav) Echo(delay time.Duration, feedback, mix float64) {
+	d := int(delay.Seconds() * float64(w.SampleRate))
+	if d <= 0 {
+		return
+	}
+	for ch := 0; ch < int(w.NumChannels); ch++ {
+		x := w.channel(ch)
+		wet := make([]float64, len(x))
+		for i := d; i < len(x); i++ {
+			wet[i] = x[i-d] + feedback*wet[i-d]
+		}
+		for i := range x {
+			x[i] += mix * wet[i]
+		}
+		w.setChannel(ch, x)
+	}
+}
+
+// BitCrush quantizes w to bits of resolution, adding the coarse
+// quantization noise of a low bit depth while keeping BitsPerSample. It does
+// nothing if bits is not below BitsPerSample.
+func (w *Wav) BitCrush(bits int) {
+	if bits < 1 || bits >= int(w.BitsPerSample) {
+		return
+	}
+	levels := float64(int64(1) << (bits - 1))
+	for ch := 0; ch < int(w.NumChannels); ch++ {
+		x := w.channel(ch)
+		for i := range x {
+			x[i] = math.Min(levels-1, math.Floor(x[i]*levels+0.5)) / levels
+		}
+		w.setChannel(ch, x)
+	}
+}
+
+// Saturate soft-clips w through a tanh curve, drive setting how hard: small
+// values barely bend the signal, large ones approach hard clipping. The
+// curve is scaled to keep the peak level of w, so raising the drive adds
+// harmonics and density but no gain.
+func (w *Wav) Saturate(drive float64) {
+	peak := w.Peak()
+	if drive <= 0 || peak == 0 {
+		return
+	}
+	scale := peak / math.Tanh(drive)
+	for ch := 0; ch < int(w.NumChannels); ch++ {
+		x := w.channel(ch)
+		for i := range x {
+			x[i] = scale * math.Tanh(drive*x[i]/peak)
+		}
+		w.setChannel(ch, x)
+	}
+}
@@ -0,0 +1,221 @@
+package wav
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// maxAbs returns the largest absolute sample value in data[from:to].
+func maxAbs(data [][]int, from, to int) int {
+	m := 0
+	for _, sample := range data[from:to] {
+		for _, v := range sample {
+			if v < 0 {
+				v = -v
+			}
+			if v > m {
+				m = v
+			}
+		}
+	}
+	return m
+}
+
+func TestNoiseGate(t *testing.T) {
+	const sampleRate = 8000
+	r := rand.New(rand.NewSource(1))
+	data := make([][]int, 3*sampleRate)
+	tone := sineData(440, sampleRate, sampleRate, 0.5)
+	for i := range data {
+		data[i] = []int{r.Intn(201) - 100}
+		if i >= sampleRate && i < 2*sampleRate {
+			data[i][0] += tone[i-sampleRate][0]
+		}
+	}
+	w := testWav(sampleRate, data)
+
+	w.NoiseGate(-30, 0.001, 0.05)
+	if m := maxAbs(w.Data, 0, sampleRate); m != 0 {
+		t.Fatalf("Expected the leading noise to be gated, got peak %d", m)
+	}
+	if m := maxAbs(w.Data, 2*sampleRate+sampleRate/2, 3*sampleRate); m != 0 {
+		t.Fatalf("Expected the trailing noise to be gated, got peak %d", m)
+	}
+	if m := maxAbs(w.Data, sampleRate+sampleRate/4, 2*sampleRate); m < 16000 {
+		t.Fatalf("Expected the tone to pass the gate, got peak %d", m)
+	}
+}
+
+// peakToAverage returns the ratio of the peak to the RMS of channel 0.
+func peakToAverage(w *Wav) float64 {
+	var peak, sum float64
+	for _, v := range w.channel(0) {
+		peak = math.Max(peak, math.Abs(v))
+		sum += v * v
+	}
+	return peak / math.Sqrt(sum/float64(len(w.Data)))
+}
+
+func TestCompress(t *testing.T) {
+	const sampleRate = 8000
+	data := sineData(440, sampleRate, sampleRate, 0.1)
+	burst := sineData(440, sampleRate, sampleRate, 0.9)
+	for i := sampleRate / 2; i < sampleRate/2+sampleRate/10; i++ {
+		data[i] = burst[i]
+	}
+	w := testWav(sampleRate, data)
+
+	before := peakToAverage(w)
+	w.Compress(-20, 8, 0, 0.05)
+	after := peakToAverage(w)
+	if after >= before*0.8 {
+		t.Fatalf("Expected compression to reduce the peak-to-average ratio. Before %v. After %v", before, after)
+	}
+}
+
+func TestPan(t *testing.T) {
+	w := testWav(8000, sineData(440, 8000, 1000, 0.5))
+	if err := w.Pan(-1); err != nil {
+		t.Fatalf("Pan returned an error: %v", err)
+	}
+	if w.NumChannels != 2 || w.BlockAlign != 4 {
+		t.Fatalf("Expected mono to be upmixed to stereo. Got %+v", w.WavHeader)
+	}
+	if m := maxAbs(w.Data, 0, len(w.Data)); m < 16000 {
+		t.Fatalf("Expected the left channel to keep the signal, got peak %d", m)
+	}
+	for i, sample := range w.Data {
+		if sample[1] != 0 || w.Data16[i][1] != 0 {
+			t.Fatalf("Expected the right channel to be silent, sample %d is %d", i, sample[1])
+		}
+	}
+
+	// The total power is the same at every position.
+	for _, position := range []float64{-0.5, 0, 0.3, 1} {
+		panned := testWav(8000, [][]int{{10000}})
+		if err := panned.Pan(position); err != nil {
+			t.Fatal(err)
+		}
+		l, r := float64(panned.Data[0][0]), float64(panned.Data[0][1])
+		if power := l*l + r*r; math.Abs(power-1e8) > 1e8*1e-3 {
+			t.Errorf("Position %v: expected a power of 1e8, got %v from %v", position, power, panned.Data[0])
+		}
+	}
+
+	center := testWav(8000, [][]int{{1000, 1000}})
+	if err := center.Pan(0); err != nil {
+		t.Fatal(err)
+	}
+	if center.Data[0][0] != 707 || center.Data[0][1] != 707 {
+		t.Fatalf("Expected a centered pan to be 3 dB down on each channel, got %v", center.Data[0])
+	}
+
+	if err := center.Pan(1.5); err == nil {
+		t.Fatal("Expected an error for a position out of range")
+	}
+}
+
+func TestTremolo(t *testing.T) {
+	const sampleRate, rate = 8000, 4
+	data := make([][]int, sampleRate)
+	for i := range data {
+		data[i] = []int{16384}
+	}
+	w := testWav(sampleRate, data)
+
+	w.Tremolo(rate, 0.5)
+	period := sampleRate / rate
+	for i := 0; i+period < len(w.Data); i++ {
+		if d := w.Data[i][0] - w.Data[i+period][0]; d > 1 || d < -1 {
+			t.Fatalf("Expected the modulation to repeat every %d samples, sample %d is %d but %d is %d", period, i, w.Data[i][0], i+period, w.Data[i+period][0])
+		}
+	}
+	if v := w.Data[period/4][0]; v != 8192 {
+		t.Fatalf("Expected the gain to dip to 0.5 at the modulation peak, got %d", v)
+	}
+	if v := w.Data[3*period/4][0]; v != 16384 {
+		t.Fatalf("Expected unity gain at the modulation trough, got %d", v)
+	}
+}
+
+func TestEcho(t *testing.T) {
+	const sampleRate = 8000
+	data := make([][]int, sampleRate)
+	for i := range data {
+		data[i] = []int{0}
+	}
+	data[0][0] = 16384
+	w := testWav(sampleRate, data)
+
+	w.Echo(100*time.Millisecond, 0.5, 1)
+	expected := map[int]int{0: 16384}
+	for i, v := 800, 16384; i < sampleRate; i, v = i+800, v/2 {
+		expected[i] = v
+	}
+	for i, sample := range w.Data {
+		if sample[0] != expected[i] {
+			t.Fatalf("Sample %d. Expected %d. Got %d", i, expected[i], sample[0])
+		}
+	}
+}
+
+func TestBitCrush(t *testing.T) {
+	wav := testWav(8000, sineData(100, 8000, 800, 0.9))
+	wav.BitCrush(4)
+	if wav.BitsPerSample != 16 {
+		t.Fatalf("Expected BitsPerSample to be kept, got %d", wav.BitsPerSample)
+	}
+
+	// 4 bits leave 16 levels, 4096 apart in 16-bit samples.
+	levels := make(map[int]bool)
+	for i, sample := range wav.Data {
+		if sample[0]%4096 != 0 {
+			t.Fatalf("Sample %d is %d, not on a 4-bit step", i, sample[0])
+		}
+		levels[sample[0]] = true
+	}
+	if len(levels) < 10 || len(levels) > 16 {
+		t.Fatalf("Expected up to 16 distinct levels, got %d", len(levels))
+	}
+}
+
+func TestSaturate(t *testing.T) {
+	var prev float64
+	for _, drive := range []float64{0.5, 2, 8} {
+		wav := testWav(44100, sineData(1000, 44100, 44100, 0.9))
+		wav.Saturate(drive)
+		thd, err := THD(wav, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if thd <= prev {
+			t.Errorf("Drive %v: expected THD to rise above %v, got %v", drive, prev, thd)
+		}
+		prev = thd
+		if peak := wav.Peak(); math.Abs(peak-0.9) > 0.01 {
+			t.Errorf("Drive %v: expected the peak to stay at 0.9, got %v", drive, peak)
+		}
+	}
+}
+
+func TestApplyGainEnvelope(t *testing.T) {
+	data := make([][]int, 101)
+	for i := range data {
+		data[i] = []int{10000, -10000}
+	}
+	wav := testWav(8000, data)
+	if err := wav.ApplyGainEnvelope([]float64{0, 1}); err != nil {
+		t.Fatalf("ApplyGainEnvelope returned an error: %v", err)
+	}
+	for i, sample := range wav.Data {
+		if expected := 100 * i; sample[0] != expected || sample[1] != -expected {
+			t.Fatalf("Sample %d: expected ±%d, got %v", i, expected, sample)
+		}
+	}
+
+	if err := wav.ApplyGainEnvelope(nil); err == nil {
+		t.Fatal("Expected an error for an empty envelope")
+	}
+}
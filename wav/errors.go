@@ -0,0 +1,41 @@
+package wav
+
+import (
+	"errors"
+)
+
+// Errors returned by this package can be matched against these with
+// errors.Is to tell the broad kind of failure apart, while their messages
+// give the details.
+var (
+	// ErrNotRIFF is returned for input that is not a RIFF WAVE file.
+	ErrNotRIFF = errors.New("wav: not a RIFF WAVE file")
+	// ErrUnsupportedFormat is returned for a valid file whose audio format
+	// or sample width cannot be decoded or encoded.
+	ErrUnsupportedFormat = errors.New("wav: unsupported format")
+	// ErrTruncated is returned when input ends before the file does.
+	ErrTruncated = errors.New("wav: file is truncated")
+	// ErrTooLarge is returned when input exceeds a size limit set by the
+	// caller, or is too large to decode in memory on this platform.
+	ErrTooLarge = errors.New("wav: input too large")
+)
+
+// kindError is an error with its own message that matches kind, one of the
+// sentinel errors above, under errors.Is.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// newError returns an error with the message msg matching kind.
+func newError(kind error, msg string) error {
+	return &kindError{kind, msg}
+}
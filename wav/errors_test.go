@@ -0,0 +1,115 @@
+package wav
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	float := fmtChunk(1, 8000, 32)
+	float[8] = 3 // WAVE_FORMAT_IEEE_FLOAT
+
+	// Headers whose BlockAlign cannot hold one value per channel.
+	narrow := fmtChunk(2, 8000, 8)
+	narrow[20] = 1
+	uneven := fmtChunk(2, 8000, 16)
+	uneven[20] = 5
+	wide := fmtChunk(1, 8000, 16)
+	wide[20] = 16
+
+	for _, test := range []struct {
+		name  string
+		input []byte
+		kind  error
+	}{
+		{"unsupported format", riff(float, chunk("data", make([]byte, 8))), ErrUnsupportedFormat},
+		{"block align below channels", riff(narrow, chunk("data", make([]byte, 8))), ErrUnsupportedFormat},
+		{"uneven block align", riff(uneven, chunk("data", make([]byte, 10))), ErrUnsupportedFormat},
+		{"container too wide", riff(wide, chunk("data", make([]byte, 16))), ErrUnsupportedFormat},
+		{"FLAC", append([]byte("fLaC\x00\x00\x00\x22"), make([]byte, 60)...), ErrNotRIFF},
+		{"not RIFF", append([]byte("RIFX"), make([]byte, 60)...), ErrNotRIFF},
+		{"short", []byte("RIFF"), ErrTruncated},
+		{"truncated chunk", riff(fmtChunk(1, 8000, 16), chunk("data", []byte{1, 0}))[:30], ErrTruncated},
+		{"data marker at end", append(riff(fmtChunk(1, 8000, 16)), "data"...), ErrTruncated},
+		{"data header at end", riff(fmtChunk(1, 8000, 16), chunk("data", []byte{1, 0}))[:44], ErrTruncated},
+	} {
+		_, err := ReadWav(bytes.NewReader(test.input))
+		if !errors.Is(err, test.kind) {
+			t.Errorf("%s: expected an error matching %q, got %v", test.name, test.kind, err)
+		}
+		if test.kind == ErrUnsupportedFormat && test.name != "unsupported format" {
+			if _, err = StreamWav(bytes.NewReader(test.input)); !errors.Is(err, test.kind) {
+				t.Errorf("%s: expected StreamWav to fail with %q, got %v", test.name, test.kind, err)
+			}
+		}
+	}
+
+	if _, err := ReadRawPCM(bytes.NewReader(nil), 8000, 12, 1); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ReadRawPCM of 12-bit data to fail with ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestReadWavLimit(t *testing.T) {
+	file := riff(fmtChunk(1, 8000, 16), chunk("data", make([]byte, 1000)))
+
+	wav, err := ReadWavLimit(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("ReadWavLimit of input within the limit returned an error: %v", err)
+	}
+	if wav.NumSamples != 500 {
+		t.Fatalf("Expected 500 samples, got %d", wav.NumSamples)
+	}
+
+	// A header declaring a huge data chunk must not be trusted either.
+	huge := riff(fmtChunk(1, 8000, 16), chunk("data", make([]byte, 1000)))
+	copy(huge[40:44], []byte{0xff, 0xff, 0xff, 0xff})
+	for _, input := range [][]byte{file, huge} {
+		if _, err = ReadWavLimit(bytes.NewReader(input), int64(len(input)-1)); !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("Expected an error matching ErrTooLarge, got %v", err)
+		}
+	}
+}
+
+func TestLargeDataChunk(t *testing.T) {
+	// A data chunk declaring 4 GiB less a few bytes, of which none are
+	// present.
+	header := riff(fmtChunk(2, 44100, 16))
+	header = append(header, "data\xf0\xff\xff\xff"...)
+
+	wav, err := StreamWav(bytes.NewReader(header))
+	if strconv.IntSize == 32 {
+		if !errors.Is(err, ErrTooLarge) {
+			t.Fatalf("Expected ErrTooLarge on a 32-bit platform, got %v", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("StreamWav returned an error: %v", err)
+	}
+	if expected := 0xfffffff0 / 4; wav.NumSamples != expected {
+		t.Fatalf("Expected %d samples, got %d", expected, wav.NumSamples)
+	}
+}
+
+func TestMalformedLayoutDoesNotPanic(t *testing.T) {
+	// Every combination of a small channel count, block align and bit depth
+	// must either decode or return an error.
+	for channels := 0; channels <= 3; channels++ {
+		for blockAlign := 0; blockAlign <= 12; blockAlign++ {
+			for _, bits := range []uint16{0, 4, 8, 12, 16, 24, 32, 64} {
+				header := fmtChunk(1, 8000, 16)
+				header[10] = byte(channels)
+				header[20] = byte(blockAlign)
+				header[22] = byte(bits)
+				file := riff(header, chunk("data", make([]byte, 24)))
+
+				ReadWav(bytes.NewReader(file))
+				if wav, err := StreamWav(bytes.NewReader(file)); err == nil {
+					wav.ReadSamples(4)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package wav
+
+import (
+	"math"
+
+	"github.com/mjibson/go-dsp/window"
+)
+
+// biquad is a second order IIR filter section with coefficients normalized
+// so that a0 is 1.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+
+	// state for the transposed direct form II
+	z1, z2 float64
+}
+
+// newBiquad returns a biquad with the coefficients b and a normalized by a0.
+func newBiquad(b0, b1, b2, a0, a1, a2 float64) *biquad {
+	return &biquad{
+		b0: b0 / a0,
+		b1: b1 / a0,
+		b2: b2 / a0,
+		a1: a1 / a0,
+		a2: a2 / a0,
+	}
+}
+
+// process filters x in place and returns it.
+func (f *biquad) process(x []float64) []float64 {
+	for i, v := range x {
+		y := f.b0*v + f.z1
+		f.z1 = f.b1*v - f.a1*y + f.z2
+		f.z2 = f.b2*v - f.a2*y
+		x[i] = y
+	}
+	return x
+}
+
+// coefficients returns the numerator and denominator of f, with a[0] = 1.
+func (f *biquad) coefficients() (b, a []float64) {
+	return []float64{f.b0, f.b1, f.b2}, []float64{1, f.a1, f.a2}
+}
+
+// DesignLowPass returns the coefficients of a second order low-pass filter
+// with the given cutoff frequency in Hz and quality factor, using the
+// formulas of Robert Bristow-Johnson's Audio EQ Cookbook. The result is
+// normalized so that a[0] is 1; q of 1/sqrt(2) gives a Butterworth response.
+func DesignLowPass(cutoff, sampleRate, q float64) (b, a []float64) {
+	return lowPass(cutoff, sampleRate, q).coefficients()
+}
+
+// lowPass returns a second order low-pass biquad.
+func lowPass(cutoff, sampleRate, q float64) *biquad {
+	w0 := 2 * math.Pi * cutoff / sampleRate
+	cos := math.Cos(w0)
+	alpha := math.Sin(w0) / (2 * q)
+	return newBiquad((1-cos)/2, 1-cos, (1-cos)/2, 1+alpha, -2*cos, 1-alpha)
+}
+
+// bandPass returns a second order band-pass biquad with unity gain at center.
+func bandPass(center, sampleRate, q float64) *biquad {
+	w0 := 2 * math.Pi * center / sampleRate
+	alpha := math.Sin(w0) / (2 * q)
+	return newBiquad(alpha, 0, -alpha, 1+alpha, -2*math.Cos(w0), 1-alpha)
+}
+
+// FIRFilter returns data filtered by the FIR filter with coefficients taps,
+// computed by direct convolution. The result has the length of data, with
+// samples before the start of data taken as 0. Convolve is faster for long
+// filters.
+func FIRFilter(data, taps []float64) []float64 {
+	y := make([]float64, len(data))
+	for n := range y {
+		var sum float64
+		for k := 0; k < len(taps) && k <= n; k++ {
+			sum += taps[k] * data[n-k]
+		}
+		y[n] = sum
+	}
+	return y
+}
+
+// DesignFIRLowPass returns numTaps coefficients of a linear phase FIR
+// low-pass filter with the given cutoff frequency in Hz, for use with
+// FIRFilter. The taps are a Hamming-windowed sinc, scaled for unity gain at
+// DC; more taps give a steeper transition and a longer delay of
+// (numTaps-1)/2 samples.
+func DesignFIRLowPass(cutoff, sampleRate float64, numTaps int) []float64 {
+	if numTaps < 1 {
+		return nil
+	}
+	fc := cutoff / sampleRate
+	taps := window.Hamming(numTaps)
+	var sum float64
+	for i := range taps {
+		taps[i] *= 2 * fc * sinc(2*fc*(float64(i)-float64(numTaps-1)/2))
+		sum += taps[i]
+	}
+	for i := range taps {
+		taps[i] /= sum
+	}
+	return taps
+}
+
+// BandType is the shape of an Equalizer band.
+type BandType int
+
+const (
+	Peaking BandType = iota
+	LowShelf
+	HighShelf
+)
+
+// Band is one band of an Equalizer. Frequency is the center frequency of a
+// Peaking band or the midpoint of a shelf, in Hz.
+type Band struct {
+	Type      BandType
+	Frequency float64
+	GainDB    float64
+	Q         float64
+}
+
+// design returns the biquad for b at sampleRate, following the formulas of
+// Robert Bristow-Johnson's Audio EQ Cookbook.
+func (b Band) design(sampleRate float64) *biquad {
+	A := math.Pow(10, b.GainDB/40)
+	w0 := 2 * math.Pi * b.Frequency / sampleRate
+	cos := math.Cos(w0)
+	alpha := math.Sin(w0) / (2 * b.Q)
+	beta := 2 * math.Sqrt(A) * alpha
+
+	switch b.Type {
+	case LowShelf:
+		return newBiquad(
+			A*((A+1)-(A-1)*cos+beta),
+			2*A*((A-1)-(A+1)*cos),
+			A*((A+1)-(A-1)*cos-beta),
+			(A+1)+(A-1)*cos+beta,
+			-2*((A-1)+(A+1)*cos),
+			(A+1)+(A-1)*cos-beta,
+		)
+	case HighShelf:
+		return newBiquad(
+			A*((A+1)+(A-1)*cos+beta),
+			-2*A*((A-1)+(A+1)*cos),
+			A*((A+1)+(A-1)*cos-beta),
+			(A+1)-(A-1)*cos+beta,
+			2*((A-1)-(A+1)*cos),
+			(A+1)-(A-1)*cos-beta,
+		)
+	}
+	return newBiquad(1+alpha*A, -2*cos, 1-alpha*A, 1+alpha/A, -2*cos, 1-alpha/A)
+}
+
+// Equalizer is a parametric equalizer made of a chain of biquad bands.
+type Equalizer struct {
+	filters []*biquad
+}
+
+// NewEqualizer returns an Equalizer applying bands in order at sampleRate.
+func NewEqualizer(sampleRate float64, bands ...Band) *Equalizer {
+	e := new(Equalizer)
+	for _, b := range bands {
+		e.filters = append(e.filters, b.design(sampleRate))
+	}
+	return e
+}
+
+// Process returns x filtered by every band. The filter state carries over
+// between calls, so a long signal may be processed in consecutive pieces.
+func (e *Equalizer) Process(x []float64) []float64 {
+	y := make([]float64, len(x))
+	copy(y, x)
+	for _, f := range e.filters {
+		f.process(y)
+	}
+	return y
+}
+
+// Frequency range covered by FilterBank.
+const (
+	filterBankLow  = 50
+	filterBankHigh = 0.9 // fraction of the Nyquist frequency
+)
+
+// FilterBankEdges returns the numBands+1 logarithmically spaced band edges,
+// in Hz, used by FilterBank.
+func FilterBankEdges(numBands int, sampleRate float64) []float64 {
+	if numBands < 1 {
+		return nil
+	}
+	high := filterBankHigh * sampleRate / 2
+	edges := make([]float64, numBands+1)
+	for i := range edges {
+		edges[i] = filterBankLow * math.Pow(high/filterBankLow, float64(i)/float64(numBands))
+	}
+	return edges
+}
+
+// FilterBank splits the mono signal x, sampled at sampleRate, into numBands
+// band-pass filtered copies whose bands are spaced logarithmically from
+// 50 Hz to 90% of the Nyquist frequency, as given by FilterBankEdges. This
+// gives a coarse spectral view, such as for a level display, without an FFT.
+// It returns nil if numBands is less than 1.
+func FilterBank(x []float64, numBands int, sampleRate float64) [][]float64 {
+	if numBands < 1 {
+		return nil
+	}
+	edges := FilterBankEdges(numBands, sampleRate)
+	bands := make([][]float64, numBands)
+	for i := range bands {
+		lo, hi := edges[i], edges[i+1]
+		center := math.Sqrt(lo * hi)
+		bands[i] = make([]float64, len(x))
+		copy(bands[i], x)
+		bandPass(center, sampleRate, center/(hi-lo)).process(bands[i])
+	}
+	return bands
+}
@@ -0,0 +1,140 @@
+package wav
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/mjibson/go-dsp/dsputils"
+	"github.com/mjibson/go-dsp/fft"
+)
+
+// magnitudeAt returns the FFT magnitude of x at freq Hz.
+func magnitudeAt(x []float64, freq, sampleRate float64) float64 {
+	X := fft.FFTReal(x)
+	return cmplx.Abs(X[int(math.Floor(freq*float64(len(x))/sampleRate+0.5))])
+}
+
+func TestEqualizer(t *testing.T) {
+	const sampleRate = 8000
+	x := make([]float64, 4*sampleRate)
+	for i := range x {
+		ti := float64(i) / sampleRate
+		x[i] = 0.1*math.Sin(2*math.Pi*100*ti) + 0.1*math.Sin(2*math.Pi*1000*ti)
+	}
+
+	eq := NewEqualizer(sampleRate, Band{Type: Peaking, Frequency: 1000, GainDB: 12, Q: 1})
+	y := eq.Process(x)
+
+	// Skip the filter's settling time.
+	x, y = x[sampleRate:], y[sampleRate:]
+	boost := 20 * math.Log10(magnitudeAt(y, 1000, sampleRate)/magnitudeAt(x, 1000, sampleRate))
+	if math.Abs(boost-12) > 0.5 {
+		t.Fatalf("Expected a 12 dB boost at 1000 Hz. Got %v dB", boost)
+	}
+	other := 20 * math.Log10(magnitudeAt(y, 100, sampleRate)/magnitudeAt(x, 100, sampleRate))
+	if math.Abs(other) > 0.5 {
+		t.Fatalf("Expected 100 Hz to be left alone. Got %v dB", other)
+	}
+
+	shelves := NewEqualizer(sampleRate,
+		Band{Type: LowShelf, Frequency: 300, GainDB: -6, Q: 0.707},
+		Band{Type: HighShelf, Frequency: 3000, GainDB: 6, Q: 0.707},
+	)
+	y = shelves.Process(x)[sampleRate:]
+	low := 20 * math.Log10(magnitudeAt(y, 100, sampleRate)/magnitudeAt(x[sampleRate:], 100, sampleRate))
+	if math.Abs(low+6) > 1 {
+		t.Fatalf("Expected a 6 dB cut at 100 Hz. Got %v dB", low)
+	}
+}
+
+func TestDesignLowPass(t *testing.T) {
+	b, a := DesignLowPass(1000, 48000, 0.7071)
+	expectedB := []float64{0.003916123487156441, 0.007832246974312881, 0.003916123487156441}
+	expectedA := []float64{1, -1.8153396116625289, 0.8310041056111547}
+	if !dsputils.PrettyClose(b, expectedB) || !dsputils.PrettyClose(a, expectedA) {
+		t.Fatalf("Coefficients do not match the cookbook.\nb: %v, expected %v\na: %v, expected %v", b, expectedB, a, expectedA)
+	}
+
+	// The DC gain of a low-pass is 1.
+	if dc := (b[0] + b[1] + b[2]) / (a[0] + a[1] + a[2]); math.Abs(dc-1) > 1e-9 {
+		t.Fatalf("Expected unity gain at DC. Got %v", dc)
+	}
+}
+
+func TestFilterBank(t *testing.T) {
+	const sampleRate = 16000
+	w := testWav(sampleRate, sineData(1000, sampleRate, sampleRate, 0.5))
+
+	bands := FilterBank(w.mono(), 8, sampleRate)
+	if len(bands) != 8 {
+		t.Fatalf("Expected 8 bands, got %d", len(bands))
+	}
+	edges := FilterBankEdges(8, sampleRate)
+
+	energy := make([]float64, len(bands))
+	loudest := 0
+	for i, band := range bands {
+		if len(band) != len(w.Data) {
+			t.Fatalf("Band %d has %d samples, expected %d", i, len(band), len(w.Data))
+		}
+		for _, v := range band {
+			energy[i] += v * v
+		}
+		if energy[i] > energy[loudest] {
+			loudest = i
+		}
+	}
+	if edges[loudest] > 1000 || edges[loudest+1] < 1000 {
+		t.Fatalf("Expected the band containing 1000 Hz to be loudest, got %v-%v Hz", edges[loudest], edges[loudest+1])
+	}
+	for i := range energy {
+		if i != loudest && energy[i] > energy[loudest]/2 {
+			t.Fatalf("Expected energy to concentrate in band %d. Energies: %v", loudest, energy)
+		}
+	}
+
+	for _, n := range []int{0, -1} {
+		if bands := FilterBank(w.mono(), n, sampleRate); bands != nil {
+			t.Errorf("Expected nil for %d bands, got %v", n, bands)
+		}
+	}
+}
+
+func TestFIRFilter(t *testing.T) {
+	data := []float64{1, 2, 3, 4, 5, -5, 0}
+	taps := []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}
+	expected := []float64{1.0 / 3, 1, 2, 3, 4, 4.0 / 3, 0}
+	if y := FIRFilter(data, taps); !dsputils.PrettyClose(y, expected) {
+		t.Fatalf("Moving average differs. Expected %v. Got %v", expected, y)
+	}
+}
+
+func TestDesignFIRLowPass(t *testing.T) {
+	const sampleRate = 8000
+	taps := DesignFIRLowPass(1000, sampleRate, 101)
+	if len(taps) != 101 {
+		t.Fatalf("Expected 101 taps, got %d", len(taps))
+	}
+	for i := range taps {
+		if math.Abs(taps[i]-taps[len(taps)-1-i]) > 1e-12 {
+			t.Fatal("Expected symmetric taps")
+		}
+	}
+
+	x := make([]float64, 4*sampleRate)
+	for i := range x {
+		ti := float64(i) / sampleRate
+		x[i] = 0.1*math.Sin(2*math.Pi*200*ti) + 0.1*math.Sin(2*math.Pi*3000*ti)
+	}
+	y := FIRFilter(x, taps)
+	x, y = x[sampleRate:], y[sampleRate:]
+	pass := 20 * math.Log10(magnitudeAt(y, 200, sampleRate)/magnitudeAt(x, 200, sampleRate))
+	if math.Abs(pass) > 0.1 {
+		t.Fatalf("Expected 200 Hz to pass. Got %v dB", pass)
+	}
+	stop := 20 * math.Log10(magnitudeAt(y, 3000, sampleRate)/magnitudeAt(x, 3000, sampleRate))
+	if stop > -40 {
+		t.Fatalf("Expected 3000 Hz to be cut by at least 40 dB. Got %v dB", stop)
+	}
+}
@@ -0,0 +1,28 @@
+package wav
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIntegratedLoudness(t *testing.T) {
+	// EBU Tech 3341: a stereo 1 kHz sine at -23 dBFS reads -23 LUFS.
+	const sampleRate = 48000
+	tone := sineData(1000, sampleRate, 5*sampleRate, math.Pow(10, -23.0/20))
+	data := make([][]int, len(tone))
+	for i := range tone {
+		data[i] = []int{tone[i][0], tone[i][0]}
+	}
+
+	lufs, err := IntegratedLoudness(testWav(sampleRate, data))
+	if err != nil {
+		t.Fatalf("IntegratedLoudness returned an error: %v", err)
+	}
+	if math.Abs(lufs+23) > 0.1 {
+		t.Fatalf("Expected -23 LUFS. Got %v", lufs)
+	}
+
+	if _, err = IntegratedLoudness(testWav(sampleRate, data[:100])); err == nil {
+		t.Fatal("Expected an error measuring a Wav shorter than one block")
+	}
+}
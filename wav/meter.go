@@ -0,0 +1,63 @@
+package wav
+
+import (
+	"math"
+)
+
+// Meter measures the RMS level of a stream over a sliding window, e.g. to
+// drive a VU meter from the samples returned by StreamedWav.ReadSamples.
+type Meter struct {
+	scale func(int) float64
+
+	squares []float64 // ring buffer of squared normalized values
+	next    int
+	filled  bool
+	sum     float64
+}
+
+// NewMeter returns a Meter for samples in the format of header, averaging
+// over the last window samples of all channels.
+func NewMeter(header WavHeader, window int) *Meter {
+	w := &Wav{WavHeader: header}
+	size := window * int(header.NumChannels)
+	if size < 1 {
+		size = 1
+	}
+	return &Meter{
+		scale:   w.normalize,
+		squares: make([]float64, size),
+	}
+}
+
+// Push adds interleaved sample values to the meter.
+func (m *Meter) Push(samples []int) {
+	for _, v := range samples {
+		x := m.scale(v)
+		m.sum += x*x - m.squares[m.next]
+		m.squares[m.next] = x * x
+		m.next++
+		if m.next == len(m.squares) {
+			m.next = 0
+			m.filled = true
+			// Recompute the sum once per window to stop rounding errors
+			// from accumulating.
+			m.sum = 0
+			for _, s := range m.squares {
+				m.sum += s
+			}
+		}
+	}
+}
+
+// Level returns the RMS of the window relative to full scale. Before a full
+// window has been pushed it covers the values pushed so far.
+func (m *Meter) Level() float64 {
+	n := len(m.squares)
+	if !m.filled {
+		n = m.next
+	}
+	if n == 0 {
+		return 0
+	}
+	return math.Sqrt(math.Max(m.sum, 0) / float64(n))
+}
@@ -0,0 +1,55 @@
+package wav
+
+import (
+	"math"
+	"os"
+	"testing"
+)
+
+func TestMeter(t *testing.T) {
+	header := WavHeader{NumChannels: 2, BitsPerSample: 16}
+	m := NewMeter(header, 100)
+	if m.Level() != 0 {
+		t.Fatalf("Expected an empty meter to read 0, got %v", m.Level())
+	}
+
+	for i := 0; i < 1000; i++ {
+		m.Push([]int{32000, -32000})
+	}
+	for i := 0; i < 1000; i++ {
+		m.Push([]int{16384, -16384})
+	}
+	if level := m.Level(); math.Abs(level-0.5) > 1e-9 {
+		t.Fatalf("Expected the meter to converge to 0.5, got %v", level)
+	}
+}
+
+func TestMeterWithStreamedWav(t *testing.T) {
+	testFile, err := os.Open(SmallWavFileName)
+	if err != nil {
+		t.Fatalf("Unable to run test, can't open test file '%s'", SmallWavFileName)
+	}
+	defer testFile.Close()
+	wav, err := StreamWav(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMeter(wav.WavHeader, wav.NumSamples)
+	for samples, err := wav.ReadSamples(4096); err == nil; samples, err = wav.ReadSamples(4096) {
+		for _, sample := range samples {
+			m.Push(sample)
+		}
+	}
+
+	if _, err = testFile.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	full, err := ReadWav(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(m.Level()-full.RMS()) > 1e-6 {
+		t.Fatalf("Expected the meter to read the file's RMS %v, got %v", full.RMS(), m.Level())
+	}
+}
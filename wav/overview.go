@@ -0,0 +1,67 @@
+package wav
+
+import (
+	"errors"
+	"io"
+)
+
+// overviewBlock is the most samples OverviewPeaks reads for each bucket.
+const overviewBlock = 1024
+
+// OverviewPeaks splits the data of the PCM wav file read from r into buckets
+// runs of consecutive samples and returns the smallest and largest sample
+// value of each, over all channels, as min, max pairs: 2*buckets values in
+// all. To draw a waveform overview of a long file quickly, only up to 1024
+// samples from the start of each bucket are read, seeking past the rest, so
+// a peak later in a bucket may be missed. Sample values are as in Data, and
+// buckets holding no samples, when there are more buckets than samples, are
+// given the value of silence.
+func OverviewPeaks(r io.ReadSeeker, buckets int) ([]int, error) {
+	if buckets < 1 {
+		return nil, errors.New("wav: invalid number of buckets")
+	}
+	wav, err := ReadWavLazy(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Silence in 8-bit samples is 128.
+	silence := 0
+	if wav.sampleBits() == 8 {
+		silence = 128
+	}
+	peaks := make([]int, 2*buckets)
+	block := make([]byte, overviewBlock*int(wav.BlockAlign))
+	for b := 0; b < buckets; b++ {
+		start := int(int64(b) * int64(wav.NumSamples) / int64(buckets))
+		n := int(int64(b+1)*int64(wav.NumSamples)/int64(buckets)) - start
+		if n == 0 {
+			peaks[2*b], peaks[2*b+1] = silence, silence
+			continue
+		}
+		if n > overviewBlock {
+			n = overviewBlock
+		}
+
+		if _, err = r.Seek(wav.dataOffset+int64(start)*int64(wav.BlockAlign), io.SeekStart); err != nil {
+			return nil, err
+		}
+		data := block[:n*int(wav.BlockAlign)]
+		if _, err = io.ReadFull(r, data); err == io.ErrUnexpectedEOF || err == io.EOF {
+			return nil, newError(ErrTruncated, "wav: data chunk runs past the end of the file")
+		} else if err != nil {
+			return nil, err
+		}
+
+		first := readSampleFromData(data, 0, wav.WavHeader)[0]
+		lo, hi := first, first
+		for i := 0; i < n; i++ {
+			for _, v := range readSampleFromData(data, i, wav.WavHeader) {
+				lo = min(lo, v)
+				hi = max(hi, v)
+			}
+		}
+		peaks[2*b], peaks[2*b+1] = lo, hi
+	}
+	return peaks, nil
+}
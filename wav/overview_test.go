@@ -0,0 +1,56 @@
+package wav
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestOverviewPeaks(t *testing.T) {
+	// One second of a 100 Hz tone whose level steps up each quarter, with
+	// the right channel inverted.
+	data := sineData(100, 44100, 44100, 1)
+	for i := range data {
+		v := data[i][0] * (1 + 4*i/len(data)) / 4
+		data[i] = []int{v, -v}
+	}
+	var buf bytes.Buffer
+	if err := testWav(44100, data).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	peaks, err := OverviewPeaks(bytes.NewReader(buf.Bytes()), 4)
+	if err != nil {
+		t.Fatalf("OverviewPeaks returned an error: %v", err)
+	}
+	if len(peaks) != 8 {
+		t.Fatalf("Expected 4 min, max pairs, got %v", peaks)
+	}
+	for b := 0; b < 4; b++ {
+		// 1024 samples cover a whole cycle of the tone.
+		expected := 32767 * (b + 1) / 4
+		if lo, hi := peaks[2*b], peaks[2*b+1]; lo > -expected+10 || lo < -expected-10 || hi < expected-10 || hi > expected+10 {
+			t.Errorf("Bucket %d: expected peaks near ±%d, got %d, %d", b, expected, lo, hi)
+		}
+	}
+
+	if peaks, err = OverviewPeaks(bytes.NewReader(buf.Bytes()), 100000); err != nil || len(peaks) != 200000 {
+		t.Fatalf("Expected more buckets than samples to work, got %d values and %v", len(peaks), err)
+	}
+	// Buckets without samples are silent, which for 8-bit samples is 128.
+	buf.Reset()
+	w8 := newWav(WavHeader{AudioFormat: 1, NumChannels: 1, SampleRate: 8000, BitsPerSample: 8}, [][]int{{200}, {60}})
+	if err = w8.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if peaks, err = OverviewPeaks(bytes.NewReader(buf.Bytes()), 4); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{128, 128, 200, 200, 128, 128, 60, 60}; !reflect.DeepEqual(peaks, expected) {
+		t.Fatalf("Expected %v, got %v", expected, peaks)
+	}
+
+	if _, err = OverviewPeaks(bytes.NewReader(buf.Bytes()), 0); err == nil {
+		t.Fatal("Expected an error for 0 buckets")
+	}
+}
@@ -0,0 +1,45 @@
+package wav
+
+import (
+	"io"
+)
+
+// pipeBlock is the number of samples SampleChannel reads at a time.
+const pipeBlock = 4096
+
+// SampleChannel streams the wav file read from r, sending each decoded
+// sample, indexed by channel, in order on the first channel returned, which
+// has a buffer of the given size. Once the data chunk is exhausted or an
+// error occurs both channels are closed; an error is sent on the second
+// channel before it is closed. Receivers must drain the sample channel, or
+// the goroutine decoding r never exits.
+func SampleChannel(r io.Reader, buffer int) (<-chan []int, <-chan error) {
+	samples := make(chan []int, buffer)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(samples)
+		defer close(errc)
+
+		wav, err := StreamWav(r)
+		if err != nil {
+			errc <- err
+			return
+		}
+		for {
+			block, err := wav.readFullSamples(pipeBlock)
+			for _, sample := range block {
+				samples <- sample
+			}
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				errc <- err
+				return
+			}
+		}
+	}()
+
+	return samples, errc
+}
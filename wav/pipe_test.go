@@ -0,0 +1,49 @@
+package wav
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSampleChannel(t *testing.T) {
+	b, err := ioutil.ReadFile(SmallWavFileName)
+	if err != nil {
+		t.Fatalf("Unable to run test, can't read test file '%s'", SmallWavFileName)
+	}
+	expected, err := ReadWav(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pipes and sockets may return less than was asked for.
+	for _, r := range []io.Reader{bytes.NewReader(b), iotest.OneByteReader(bytes.NewReader(b))} {
+		samples, errc := SampleChannel(r, 16)
+		var i int
+		for sample := range samples {
+			if i >= len(expected.Data) {
+				t.Fatalf("Received more than the %d samples of the file", len(expected.Data))
+			}
+			if sample[0] != expected.Data[i][0] {
+				t.Fatalf("Sample %d differs. Expected %d. Got %d", i, expected.Data[i][0], sample[0])
+			}
+			i++
+		}
+		if err := <-errc; err != nil {
+			t.Fatalf("SampleChannel returned an error: %v", err)
+		}
+		if i != len(expected.Data) {
+			t.Fatalf("Expected %d samples, got %d", len(expected.Data), i)
+		}
+	}
+
+	samples, errc := SampleChannel(bytes.NewReader([]byte("not a wav file at all")), 0)
+	for range samples {
+		t.Fatal("Expected no samples from invalid input")
+	}
+	if err := <-errc; err == nil {
+		t.Fatal("Expected an error from invalid input")
+	}
+}
@@ -0,0 +1,113 @@
+package wav
+
+import (
+	"errors"
+
+	"github.com/mjibson/go-dsp/spectral"
+)
+
+// minFundamental is the lowest frequency, in Hz, EstimateFundamental searches.
+const minFundamental = 40
+
+// octaveThreshold is how high, relative to the highest autocorrelation peak,
+// an earlier peak must be to be taken as the period instead.
+const octaveThreshold = 0.9
+
+// EstimateFundamental estimates the fundamental frequency of the mono signal
+// of w from the strongest autocorrelation peak. Comparing it against a tone
+// of known pitch helps spot a SampleRate in the header that does not match
+// the content.
+func EstimateFundamental(w *Wav) (float64, error) {
+	if w.SampleRate == 0 || len(w.Data) == 0 {
+		return 0, errors.New("wav: no data to estimate a fundamental from")
+	}
+	return fundamental(w.mono(), w.SampleRate)
+}
+
+// PitchTrack returns the fundamental frequency, estimated as by
+// EstimateFundamental, of each frame of frameSize samples of the mono signal
+// of w, frames starting hop samples apart. Frames that are silent or show no
+// periodicity have a fundamental of 0. The final frame is zero-padded.
+func (w *Wav) PitchTrack(frameSize, hop int) []float64 {
+	if w.SampleRate == 0 {
+		return nil
+	}
+	var track []float64
+	spectral.Frames(w.mono(), frameSize, hop, func(frame []float64) {
+		f, err := fundamental(frame, w.SampleRate)
+		if err != nil {
+			f = 0
+		}
+		track = append(track, f)
+	})
+	return track
+}
+
+// fundamental estimates the fundamental frequency of x, sampled at
+// sampleRate, from the strongest autocorrelation peak.
+func fundamental(x []float64, sampleRate uint32) (float64, error) {
+	maxLag := int(sampleRate) / minFundamental
+	if maxLag >= len(x) {
+		maxLag = len(x) - 1
+	}
+	r := Autocorrelation(x, maxLag)
+	if r == nil {
+		return 0, errors.New("wav: signal is silent")
+	}
+
+	// Skip the peak around lag 0, then take the highest remaining peak.
+	lag := 1
+	for lag < len(r) && r[lag] > 0 {
+		lag++
+	}
+	var peaks []int
+	best := 0
+	for ; lag < len(r)-1; lag++ {
+		if r[lag] > r[lag-1] && r[lag] >= r[lag+1] {
+			peaks = append(peaks, lag)
+			if best == 0 || r[lag] > r[best] {
+				best = lag
+			}
+		}
+	}
+	if best == 0 {
+		return 0, errors.New("wav: no periodicity found")
+	}
+	// Peaks at multiples of the period can come out slightly higher in a
+	// short or changing signal, so prefer the first nearly as high.
+	for _, p := range peaks {
+		if r[p] >= octaveThreshold*r[best] {
+			best = p
+			break
+		}
+	}
+
+	// Parabolic interpolation around the peak for sub-sample accuracy.
+	period := float64(best)
+	if d := r[best-1] - 2*r[best] + r[best+1]; d != 0 {
+		period += 0.5 * (r[best-1] - r[best+1]) / d
+	}
+	return float64(sampleRate) / period, nil
+}
+
+// Autocorrelation returns the autocorrelation of data for lags 0 through
+// maxLag, normalized so that lag 0 is 1. It returns nil if data has no energy.
+func Autocorrelation(data []float64, maxLag int) []float64 {
+	var energy float64
+	for _, v := range data {
+		energy += v * v
+	}
+	if energy == 0 || maxLag < 0 {
+		return nil
+	}
+
+	r := make([]float64, maxLag+1)
+	for k := range r {
+		var sum float64
+		for i := 0; i+k < len(data); i++ {
+			sum += data[i] * data[i+k]
+		}
+		r[k] = sum / energy
+	}
+	return r
+}
@@ -0,0 +1,92 @@
+package wav
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateFundamental(t *testing.T) {
+	for _, freq := range []float64{110, 440, 1000} {
+		w := testWav(44100, sineData(freq, 44100, 8192, 0.5))
+		f, err := EstimateFundamental(w)
+		if err != nil {
+			t.Fatalf("EstimateFundamental returned an error: %v", err)
+		}
+		if math.Abs(f-freq)/freq > 0.02 {
+			t.Fatalf("Expected fundamental ~%v Hz. Got %v Hz", freq, f)
+		}
+	}
+
+	silent := testWav(44100, make([][]int, 0))
+	if _, err := EstimateFundamental(silent); err == nil {
+		t.Fatal("Expected an error estimating the fundamental of an empty Wav")
+	}
+}
+
+func TestAutocorrelation(t *testing.T) {
+	const period = 20
+	x := make([]float64, 400)
+	for i := range x {
+		x[i] = math.Sin(2 * math.Pi * float64(i) / period)
+	}
+
+	r := Autocorrelation(x, 30)
+	if len(r) != 31 {
+		t.Fatalf("Expected 31 lags, got %d", len(r))
+	}
+	if r[0] != 1 {
+		t.Fatalf("Expected lag 0 to be 1, got %v", r[0])
+	}
+	best := period / 2
+	for k := best; k < len(r); k++ {
+		if r[k] > r[best] {
+			best = k
+		}
+	}
+	if best != period {
+		t.Fatalf("Expected peak at lag %d, got %d", period, best)
+	}
+
+	if Autocorrelation(make([]float64, 10), 5) != nil {
+		t.Fatal("Expected nil autocorrelation for a silent signal")
+	}
+}
+
+func TestPitchTrack(t *testing.T) {
+	// A linear sweep from 200 Hz to 800 Hz over two seconds.
+	const rate, seconds, f0, f1 = 8000, 2.0, 200.0, 800.0
+	data := make([][]int, rate*seconds)
+	for i := range data {
+		tm := float64(i) / rate
+		phase := 2 * math.Pi * (f0*tm + (f1-f0)*tm*tm/(2*seconds))
+		data[i] = []int{int(math.Round(16000 * math.Sin(phase)))}
+	}
+	wav := testWav(rate, data)
+
+	const frameSize, hop = 512, 256
+	track := wav.PitchTrack(frameSize, hop)
+	if len(track) == 0 {
+		t.Fatal("Expected a pitch track")
+	}
+	for i, f := range track {
+		if (i+1)*hop+frameSize > len(data) {
+			// The zero-padded tail.
+			break
+		}
+		mid := (float64(i*hop) + frameSize/2) / rate
+		expected := f0 + (f1-f0)*mid/seconds
+		if math.Abs(f-expected)/expected > 0.03 {
+			t.Errorf("Frame %d: expected ~%v Hz, got %v Hz", i, expected, f)
+		}
+		if i > 0 && f <= track[i-1] {
+			t.Errorf("Frame %d: pitch %v Hz does not rise from %v Hz", i, f, track[i-1])
+		}
+	}
+
+	silence := testWav(rate, sineData(0, rate, 1024, 0))
+	for _, f := range silence.PitchTrack(frameSize, hop) {
+		if f != 0 {
+			t.Fatalf("Expected silent frames to have no pitch, got %v Hz", f)
+		}
+	}
+}
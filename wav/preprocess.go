@@ -0,0 +1,88 @@
+package wav
+
+import (
+	"math"
+)
+
+// PreprocessOptions selects the steps Preprocess applies.
+type PreprocessOptions struct {
+	// SampleRate, if not 0 or the rate of the Wav, is the rate to resample
+	// to as Resample does.
+	SampleRate uint32
+
+	// Mono averages the channels into one.
+	Mono bool
+
+	// RemoveDC subtracts the mean of each channel.
+	RemoveDC bool
+
+	// Normalize scales the result so that its largest absolute value is 1.
+	Normalize bool
+}
+
+// Preprocess returns the samples of w, relative to full scale, after
+// resampling, mixing to mono, removing DC offset and normalizing as selected
+// by opts, in that order. The result is interleaved like the data chunk of
+// a wav file if it has more than one channel. w is left unchanged.
+func (w *Wav) Preprocess(opts PreprocessOptions) []float64 {
+	channels := make([][]float64, w.NumChannels)
+	for ch := range channels {
+		channels[ch] = w.channel(ch)
+	}
+
+	// Resample the normalized channels, without rounding or clipping them
+	// back to the bit depth of w.
+	if opts.SampleRate != 0 && w.SampleRate != 0 && opts.SampleRate != w.SampleRate {
+		ratio := float64(opts.SampleRate) / float64(w.SampleRate)
+		n := int(math.Round(float64(len(w.Data)) * ratio))
+		for ch, x := range channels {
+			channels[ch] = resampleChannel(x, n, ratio, Cubic, true)
+		}
+	}
+
+	if opts.Mono && len(channels) > 1 {
+		mono := make([]float64, len(channels[0]))
+		for _, x := range channels {
+			for i, v := range x {
+				mono[i] += v / float64(len(channels))
+			}
+		}
+		channels = [][]float64{mono}
+	}
+
+	if opts.RemoveDC {
+		for _, x := range channels {
+			var mean float64
+			for _, v := range x {
+				mean += v
+			}
+			mean /= float64(len(x))
+			for i := range x {
+				x[i] -= mean
+			}
+		}
+	}
+
+	var y []float64
+	if len(channels) > 0 {
+		y = make([]float64, 0, len(channels)*len(channels[0]))
+		for i := range channels[0] {
+			for _, x := range channels {
+				y = append(y, x[i])
+			}
+		}
+	}
+
+	if opts.Normalize {
+		var peak float64
+		for _, v := range y {
+			peak = math.Max(peak, math.Abs(v))
+		}
+		if peak > 0 {
+			for i := range y {
+				y[i] /= peak
+			}
+		}
+	}
+	return y
+}
@@ -0,0 +1,61 @@
+package wav
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPreprocess(t *testing.T) {
+	// A stereo tone with a DC offset on each channel.
+	tone := sineData(100, 16000, 16000, 0.25)
+	data := make([][]int, len(tone))
+	for i, sample := range tone {
+		data[i] = []int{sample[0] + 4000, sample[0] + 2000}
+	}
+	wav := testWav(16000, data)
+
+	y := wav.Preprocess(PreprocessOptions{SampleRate: 8000, Mono: true, RemoveDC: true, Normalize: true})
+	if len(y) != 8000 {
+		t.Fatalf("Expected 8000 mono samples at 8000 Hz, got %d", len(y))
+	}
+	var mean, peak float64
+	for _, v := range y {
+		mean += v
+		peak = math.Max(peak, math.Abs(v))
+	}
+	mean /= float64(len(y))
+	if math.Abs(mean) > 1e-9 {
+		t.Errorf("Expected zero mean, got %v", mean)
+	}
+	if peak != 1 {
+		t.Errorf("Expected a peak of 1, got %v", peak)
+	}
+	if wav.SampleRate != 16000 || wav.NumChannels != 2 || wav.Data[0][0] != 4000 {
+		t.Error("Expected Preprocess to leave the Wav unchanged")
+	}
+
+	// With no options the samples are returned interleaved.
+	y = wav.Preprocess(PreprocessOptions{})
+	if len(y) != 2*len(data) || y[0] != 4000.0/32768 || y[1] != 2000.0/32768 {
+		t.Fatalf("Expected the interleaved samples, got %d values starting %v", len(y), y[:2])
+	}
+
+	// Resampled values are neither rounded to 16 bits nor clipped, so the
+	// Cubic overshoot at a full scale step survives.
+	step := make([][]int, 100)
+	for i := range step {
+		step[i] = []int{-32768}
+		if i >= 50 {
+			step[i][0] = 32767
+		}
+	}
+	y = testWav(16000, step).Preprocess(PreprocessOptions{SampleRate: 44100})
+	var overshoot, offGrid bool
+	for _, v := range y {
+		overshoot = overshoot || v > 1
+		offGrid = offGrid || v*32768 != math.Round(v*32768)
+	}
+	if !overshoot || !offGrid {
+		t.Fatalf("Expected unclipped, unquantized samples, got overshoot %v and off-grid values %v", overshoot, offGrid)
+	}
+}
@@ -0,0 +1,129 @@
+package wav
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Repair makes the size fields of w agree with its decoded Data, so that
+// they describe the file Write produces, and returns a description of each
+// fix applied. Files with wrong sizes are common and confuse some players;
+// writing a repaired Wav fixes them.
+func (w *Wav) Repair() []string {
+	var fixes []string
+
+	if w.NumSamples != len(w.Data) {
+		fixes = append(fixes, fmt.Sprintf("sample count %d set to %d decoded samples", w.NumSamples, len(w.Data)))
+		w.NumSamples = len(w.Data)
+	}
+	if w.AudioFormat == formatPCM {
+		if size := uint32(w.NumSamples * int(w.BlockAlign)); w.ChunkSize != size || w.PartialBytes != 0 {
+			fixes = append(fixes, fmt.Sprintf("data chunk size %d set to %d bytes of samples", w.ChunkSize, size))
+			w.ChunkSize = size
+			w.PartialBytes = 0
+		}
+	}
+	if size := w.riffSize(); w.RIFFSize != size {
+		fixes = append(fixes, fmt.Sprintf("RIFF size %d set to %d", w.RIFFSize, size))
+		w.RIFFSize = size
+	}
+
+	return fixes
+}
+
+// riffSize returns the RIFF size of the file Write produces for w, given a
+// data chunk of ChunkSize bytes.
+func (w *Wav) riffSize() uint32 {
+	chunkLen := func(n int) int {
+		return 8 + n + n%2
+	}
+
+	size := 4 + chunkLen(16) + chunkLen(int(w.ChunkSize))
+	if w.Instrument != nil {
+		size += chunkLen(7)
+	}
+	if w.Sampler != nil {
+		size += chunkLen(36 + 24*len(w.Sampler.Loops) + len(w.Sampler.SamplerData))
+	}
+	if w.Peaks != nil {
+		size += chunkLen(8 + 8*len(w.Peaks))
+	}
+	for _, c := range w.RawChunks {
+		size += chunkLen(len(c.Data))
+	}
+	return uint32(size)
+}
+
+// RewriteSampleRate copies the wav file read from in to out, changing only
+// the SampleRate and ByteRate fields of its 'fmt ' chunk to match newRate.
+// Samples are copied as they are, so this relabels a file whose data is
+// right but whose rate is wrong, without decoding it.
+func RewriteSampleRate(in io.Reader, out io.Writer, newRate uint32) error {
+	if newRate == 0 {
+		return errors.New("wav: invalid sample rate")
+	}
+
+	head := make([]byte, 12)
+	n, err := io.ReadFull(in, head)
+	if err := sniff(head[:n]); err != nil {
+		return err
+	}
+	if err != nil {
+		return newError(ErrTruncated, "wav: Invalid header size")
+	}
+	if string(head[0:4]) != "RIFF" {
+		return newError(ErrNotRIFF, "wav: Header does not conatin 'RIFF'")
+	}
+	if string(head[8:12]) != "WAVE" {
+		return newError(ErrNotRIFF, "wav: Header does not contain 'WAVE'")
+	}
+	if _, err = out.Write(head); err != nil {
+		return err
+	}
+
+	for {
+		if _, err = io.ReadFull(in, head[:8]); err == io.EOF {
+			return errors.New("wav: Header does not contain 'fmt'")
+		} else if err != nil {
+			return newError(ErrTruncated, "wav: chunk header runs past the end of the file")
+		}
+		id := string(head[0:4])
+		size := int64(bLEtoUint32(head, 4))
+		if id == "data" {
+			return errors.New("wav: Header does not contain 'fmt'")
+		}
+		if _, err = out.Write(head[:8]); err != nil {
+			return err
+		}
+
+		// Chunks are word aligned.
+		if id != "fmt " {
+			if n, err := io.CopyN(out, in, size+size%2); err != nil && n < size {
+				return newError(ErrTruncated, "wav: chunk '"+id+"' runs past the end of the file")
+			}
+			continue
+		}
+
+		body := make([]byte, size+size%2)
+		if n, err := io.ReadFull(in, body); err != nil && int64(n) < size {
+			return newError(ErrTruncated, "wav: chunk 'fmt ' runs past the end of the file")
+		} else if int64(n) < int64(len(body)) {
+			body = body[:n]
+		}
+		if size < 16 {
+			return errors.New("wav: 'fmt ' chunk is too short")
+		}
+		blockAlign := uint32(bLEtoUint16(body, 12))
+		binary.LittleEndian.PutUint32(body[4:8], newRate)
+		binary.LittleEndian.PutUint32(body[8:12], newRate*blockAlign)
+		if _, err = out.Write(body); err != nil {
+			return err
+		}
+		break
+	}
+
+	_, err = io.Copy(out, in)
+	return err
+}
@@ -0,0 +1,87 @@
+package wav
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRepair(t *testing.T) {
+	file := riff(
+		fmtChunk(1, 8000, 16),
+		chunk("data", []byte{1, 0, 2, 0, 3, 0}),
+		chunk("cust", []byte{1, 2, 3}),
+	)
+	// Declare a RIFF size far too large.
+	copy(file[4:8], []byte{0xff, 0xff, 0xff, 0x7f})
+
+	wav, err := ReadWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadWav returned an error: %v", err)
+	}
+	if fixes := wav.Repair(); len(fixes) != 1 {
+		t.Fatalf("Expected one fix, got %v", fixes)
+	}
+	if wav.RIFFSize != uint32(len(file)-8) {
+		t.Fatalf("Expected the RIFF size to be repaired to %d, got %d", len(file)-8, wav.RIFFSize)
+	}
+	if fixes := wav.Repair(); len(fixes) != 0 {
+		t.Fatalf("Expected a repaired Wav to need no fixes, got %v", fixes)
+	}
+
+	var buf bytes.Buffer
+	if err = wav.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes()[4:8], []byte{byte(len(file) - 8), 0, 0, 0}) {
+		t.Fatalf("Expected the written RIFF size to match the repaired one, got %v", buf.Bytes()[4:8])
+	}
+
+	// A data chunk declaring more bytes than the file holds.
+	truncated := riff(fmtChunk(1, 8000, 16), chunk("data", []byte{1, 0, 2, 0, 3, 0, 4, 0}))
+	truncated = truncated[:len(truncated)-3]
+	wav, err = ReadWav(bytes.NewReader(truncated))
+	if err != nil {
+		t.Fatalf("ReadWav returned an error: %v", err)
+	}
+	if fixes := wav.Repair(); len(fixes) != 2 {
+		t.Fatalf("Expected the data and RIFF sizes to be fixed, got %v", fixes)
+	}
+	if wav.ChunkSize != 4 || wav.NumSamples != 2 || wav.RIFFSize != 4+24+12 {
+		t.Fatalf("Unexpected repaired header %+v", wav.WavHeader)
+	}
+}
+
+func TestRewriteSampleRate(t *testing.T) {
+	samples := []byte{1, 0, 2, 0, 3, 0, 4, 0}
+	file := riff(
+		chunk("LIST", []byte("INFOISFT\x04\x00\x00\x00go\x00\x00")),
+		fmtChunk(2, 8000, 16),
+		chunk("data", samples),
+	)
+
+	var buf bytes.Buffer
+	if err := RewriteSampleRate(bytes.NewReader(file), &buf, 44100); err != nil {
+		t.Fatalf("RewriteSampleRate returned an error: %v", err)
+	}
+	if buf.Len() != len(file) {
+		t.Fatalf("Expected %d bytes, got %d", len(file), buf.Len())
+	}
+	if !bytes.HasSuffix(buf.Bytes(), chunk("data", samples)) {
+		t.Fatal("Expected the data chunk to be copied unchanged")
+	}
+
+	wav, err := ReadWav(&buf)
+	if err != nil {
+		t.Fatalf("Unable to read back the rewritten file: %v", err)
+	}
+	if wav.SampleRate != 44100 || wav.ByteRate != 44100*4 {
+		t.Fatalf("Expected 44100 Hz at %d bytes per second, got %+v", 44100*4, wav.WavHeader)
+	}
+	if len(wav.RawChunks) != 1 || wav.RawChunks[0].ID != "LIST" {
+		t.Fatalf("Expected the LIST chunk to be kept, got %v", wav.RawChunks)
+	}
+
+	if err = RewriteSampleRate(bytes.NewReader(riff(chunk("data", samples))), &buf, 44100); err == nil {
+		t.Fatal("Expected an error for a file without a 'fmt ' chunk")
+	}
+}
@@ -0,0 +1,165 @@
+package wav
+
+import (
+	"errors"
+	"math"
+)
+
+// A ResampleKernel selects the interpolation ResampleQuality uses, trading
+// speed for fidelity.
+type ResampleKernel int
+
+const (
+	// Linear interpolates between neighbouring samples. It is the fastest
+	// kernel but lets through aliases and dulls high frequencies.
+	Linear ResampleKernel = iota
+	// Cubic interpolates with a Catmull-Rom spline through four samples.
+	Cubic
+	// Sinc uses a Blackman-windowed sinc, low-pass filtered below the lower
+	// of the two Nyquist frequencies. It is the slowest and most accurate.
+	Sinc
+)
+
+// sincZeros is the number of zero crossings on each side of the Sinc kernel.
+const sincZeros = 16
+
+// Resample converts w to the sample rate target using the Cubic kernel.
+// When lowering the rate, w is first low-pass filtered below the new Nyquist
+// frequency so that higher frequencies are removed rather than aliased.
+func (w *Wav) Resample(target uint32) {
+	w.ResampleAntiAlias(target, true)
+}
+
+// ResampleAntiAlias is like Resample, but applies the anti-aliasing filter
+// only if antiAlias is true.
+func (w *Wav) ResampleAntiAlias(target uint32, antiAlias bool) {
+	if target == 0 || target == w.SampleRate {
+		return
+	}
+	*w = *w.resample(float64(target)/float64(w.SampleRate), target, Cubic, antiAlias)
+}
+
+// ResampleQuality converts w to the sample rate target, interpolating with
+// kernel. Like Resample, it removes frequencies above the new Nyquist
+// frequency when lowering the rate.
+func (w *Wav) ResampleQuality(target uint32, kernel ResampleKernel) {
+	if target == 0 || target == w.SampleRate {
+		return
+	}
+	*w = *w.resample(float64(target)/float64(w.SampleRate), target, kernel, true)
+}
+
+// ResampleRatio returns a copy of w resampled with the Cubic kernel to ratio
+// times as many samples, e.g. 0.5 for half as many. SampleRate is scaled by
+// ratio and rounded to the nearest Hz. As with Resample, a ratio below 1
+// low-pass filters w first.
+func (w *Wav) ResampleRatio(ratio float64) (*Wav, error) {
+	rate := math.Round(float64(w.SampleRate) * ratio)
+	if !(ratio > 0) || rate < 1 || rate > math.MaxUint32 {
+		return nil, errors.New("wav: invalid resampling ratio")
+	}
+	return w.resample(ratio, uint32(rate), Cubic, true), nil
+}
+
+// ResampleToMatch returns a copy of src resampled with the Cubic kernel to
+// the SampleRate of target, so that the two can be mixed sample for sample.
+// As with Resample, src is low-pass filtered first if its rate is higher.
+func ResampleToMatch(target, src *Wav) (*Wav, error) {
+	if target.SampleRate == 0 || src.SampleRate == 0 {
+		return nil, errors.New("wav: invalid sample rate")
+	}
+	return src.resample(float64(target.SampleRate)/float64(src.SampleRate), target.SampleRate, Cubic, true), nil
+}
+
+// resample returns a copy of w with ratio times as many samples, labelled
+// with the sample rate rate. If antiAlias is true and ratio is below 1, each
+// channel is low-pass filtered before interpolating, unless kernel is Sinc,
+// which filters as it interpolates.
+func (w *Wav) resample(ratio float64, rate uint32, kernel ResampleKernel, antiAlias bool) *Wav {
+	n := int(math.Round(float64(len(w.Data)) * ratio))
+	data := make([][]int, n)
+	for i := range data {
+		data[i] = make([]int, w.NumChannels)
+	}
+	for ch := 0; ch < int(w.NumChannels); ch++ {
+		for i, v := range resampleChannel(w.channel(ch), n, ratio, kernel, antiAlias) {
+			data[i][ch] = w.denormalize(v)
+		}
+	}
+
+	header := w.WavHeader
+	header.SampleRate = rate
+	resampled := newWav(header, data)
+	resampled.RawChunks = w.RawChunks
+	return resampled
+}
+
+// resampleChannel returns n samples of x resampled to ratio times as many,
+// filtered as by resample.
+func resampleChannel(x []float64, n int, ratio float64, kernel ResampleKernel, antiAlias bool) []float64 {
+	if antiAlias && ratio < 1 && kernel != Sinc {
+		x = antiAliasFilter(x, ratio)
+	}
+	step := 1 / ratio
+	y := make([]float64, n)
+	for i := range y {
+		y[i] = interpolate(x, float64(i)*step, step, kernel)
+	}
+	return y
+}
+
+// antiAliasFilter returns x low-pass filtered for resampling to ratio times
+// as many samples, ratio being below 1. The cutoff is 90% of the new Nyquist
+// frequency, with enough taps for the transition to end at it.
+func antiAliasFilter(x []float64, ratio float64) []float64 {
+	// A Hamming window gives a transition about 3.3/taps of the rate wide.
+	taps := int(math.Ceil(3.3/(0.1*ratio))) | 1
+	h := DesignFIRLowPass(0.45*ratio, 1, taps)
+
+	// Run the filter past the end and drop its delay of half the taps, so
+	// the output lines up with x.
+	delay := (taps - 1) / 2
+	y := FIRFilter(append(append([]float64(nil), x...), make([]float64, delay)...), h)
+	return y[delay:]
+}
+
+// interpolate returns the value of x at the fractional index t. step is the
+// distance between output samples, used by Sinc to lower its cutoff when
+// downsampling. Samples outside x are taken to be those at its edges.
+func interpolate(x []float64, t, step float64, kernel ResampleKernel) float64 {
+	at := func(i int) float64 {
+		return x[max(0, min(len(x)-1, i))]
+	}
+	i := int(math.Floor(t))
+	f := t - float64(i)
+
+	switch kernel {
+	case Linear:
+		return at(i) + f*(at(i+1)-at(i))
+	case Cubic:
+		p0, p1, p2, p3 := at(i-1), at(i), at(i+1), at(i+2)
+		return p1 + 0.5*f*(p2-p0+f*(2*p0-5*p1+4*p2-p3+f*(3*(p1-p2)+p3-p0)))
+	}
+
+	cutoff := math.Min(1, 1/step)
+	width := sincZeros / cutoff
+	var y float64
+	for j := i - int(width) + 1; j <= i+int(width); j++ {
+		d := t - float64(j)
+		u := d / width
+		if u <= -1 || u >= 1 {
+			continue
+		}
+		blackman := 0.42 + 0.5*math.Cos(math.Pi*u) + 0.08*math.Cos(2*math.Pi*u)
+		y += at(j) * cutoff * sinc(cutoff*d) * blackman
+	}
+	return y
+}
+
+// sinc returns the normalized sinc function sin(πx)/(πx).
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
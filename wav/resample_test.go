@@ -0,0 +1,164 @@
+package wav
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResampleQuality(t *testing.T) {
+	// Each kernel in turn should follow a 3 kHz tone more closely, measured
+	// away from the edges where samples are repeated.
+	previous := math.Inf(1)
+	for _, kernel := range []ResampleKernel{Linear, Cubic, Sinc} {
+		wav := testWav(16000, sineData(3000, 16000, 16000, 0.5))
+		wav.ResampleQuality(44100, kernel)
+		if wav.SampleRate != 44100 || len(wav.Data) != 44100 || wav.NumSamples != 44100 {
+			t.Fatalf("Kernel %d: unexpected header %+v with %d samples", kernel, wav.WavHeader, len(wav.Data))
+		}
+
+		var sum float64
+		for i := 1000; i < len(wav.Data)-1000; i++ {
+			d := wav.normalize(wav.Data[i][0]) - 0.5*math.Sin(2*math.Pi*3000*float64(i)/44100)
+			sum += d * d
+		}
+		residual := math.Sqrt(sum / float64(len(wav.Data)-2000))
+		if residual > previous/2 {
+			t.Errorf("Kernel %d: expected a residual well below %v, got %v", kernel, previous, residual)
+		}
+		previous = residual
+	}
+}
+
+func TestResampleAliasing(t *testing.T) {
+	// A sweep from 9 to 15 kHz lies entirely above the 8 kHz Nyquist
+	// frequency of the target rate, so all that survives is aliasing.
+	sweep := func() *Wav {
+		const n = 48000
+		data := make([][]int, n)
+		for i := range data {
+			t := float64(i) / 48000
+			phase := 2 * math.Pi * (9000*t + 3000*t*t)
+			data[i] = []int{int(0.3 * 32767 * math.Sin(phase))}
+		}
+		return testWav(48000, data)
+	}
+
+	// Without the prefilter, the kernels alone decide how much aliases.
+	linear := sweep().resample(1.0/3, 16000, Linear, false)
+	sinc := sweep().resample(1.0/3, 16000, Sinc, false)
+	if sinc.RMS() > linear.RMS()/10 {
+		t.Fatalf("Expected sinc aliasing %v to be well below linear aliasing %v", sinc.RMS(), linear.RMS())
+	}
+
+	unfiltered := sweep()
+	unfiltered.ResampleAntiAlias(16000, false)
+	limit := unfiltered.RMS() / 10
+
+	for _, kernel := range []ResampleKernel{Linear, Cubic, Sinc} {
+		wav := sweep()
+		wav.ResampleQuality(16000, kernel)
+		if wav.RMS() > limit {
+			t.Errorf("Kernel %d: expected aliasing %v to be well below unfiltered aliasing %v", kernel, wav.RMS(), unfiltered.RMS())
+		}
+	}
+
+	ratio, err := sweep().ResampleRatio(1.0 / 3)
+	if err != nil {
+		t.Fatalf("ResampleRatio returned an error: %v", err)
+	}
+	if ratio.RMS() > limit {
+		t.Errorf("ResampleRatio: expected aliasing %v to be well below unfiltered aliasing %v", ratio.RMS(), unfiltered.RMS())
+	}
+
+	matched, err := ResampleToMatch(testWav(16000, sineData(0, 16000, 1, 0)), sweep())
+	if err != nil {
+		t.Fatalf("ResampleToMatch returned an error: %v", err)
+	}
+	if matched.RMS() > limit {
+		t.Errorf("ResampleToMatch: expected aliasing %v to be well below unfiltered aliasing %v", matched.RMS(), unfiltered.RMS())
+	}
+
+	var sum float64
+	y := sweep().Preprocess(PreprocessOptions{SampleRate: 16000})
+	for _, v := range y {
+		sum += v * v
+	}
+	if rms := math.Sqrt(sum / float64(len(y))); rms > limit {
+		t.Errorf("Preprocess: expected aliasing %v to be well below unfiltered aliasing %v", rms, unfiltered.RMS())
+	}
+}
+
+func TestResampleRatio(t *testing.T) {
+	wav := testWav(8000, sineData(440, 8000, 1000, 0.5))
+	doubled, err := wav.ResampleRatio(2)
+	if err != nil {
+		t.Fatalf("ResampleRatio returned an error: %v", err)
+	}
+	if len(doubled.Data) != 2000 || doubled.NumSamples != 2000 || doubled.SampleRate != 16000 {
+		t.Fatalf("Expected 2000 samples at 16000 Hz, got %d at %d Hz", len(doubled.Data), doubled.SampleRate)
+	}
+	if len(wav.Data) != 1000 || wav.SampleRate != 8000 {
+		t.Fatal("Expected ResampleRatio to leave the original unchanged")
+	}
+
+	for _, ratio := range []float64{0, -1, math.NaN()} {
+		if _, err = wav.ResampleRatio(ratio); err == nil {
+			t.Errorf("Expected an error for ratio %v", ratio)
+		}
+	}
+}
+
+func TestResampleToMatch(t *testing.T) {
+	target := testWav(16000, sineData(440, 16000, 16000, 0.25))
+	src := testWav(8000, sineData(440, 8000, 8000, 0.25))
+
+	matched, err := ResampleToMatch(target, src)
+	if err != nil {
+		t.Fatalf("ResampleToMatch returned an error: %v", err)
+	}
+	if matched.SampleRate != target.SampleRate || matched.NumSamples != target.NumSamples {
+		t.Fatalf("Expected %d samples at %d Hz, got %d at %d Hz",
+			target.NumSamples, target.SampleRate, matched.NumSamples, matched.SampleRate)
+	}
+	if src.SampleRate != 8000 || src.NumSamples != 8000 {
+		t.Fatal("Expected ResampleToMatch to leave src unchanged")
+	}
+
+	// The same tone mixed in phase doubles in level.
+	mix := make([][]int, target.NumSamples)
+	for i := range mix {
+		mix[i] = []int{target.Data[i][0] + matched.Data[i][0]}
+	}
+	if rms := testWav(16000, mix).RMS(); math.Abs(rms-2*target.RMS()) > 0.01 {
+		t.Fatalf("Expected the mix to have twice the RMS of the target, got %v and %v", rms, target.RMS())
+	}
+
+	if _, err = ResampleToMatch(&Wav{}, src); err == nil {
+		t.Fatal("Expected an error for a target without a sample rate")
+	}
+}
+
+func TestResampleAntiAlias(t *testing.T) {
+	// A 6 kHz tone lies above the 4 kHz Nyquist frequency of 8000 Hz, and
+	// without filtering aliases to 2 kHz.
+	for _, antiAlias := range []bool{true, false} {
+		wav := testWav(44100, sineData(6000, 44100, 44100, 0.5))
+		wav.ResampleAntiAlias(8000, antiAlias)
+		if wav.SampleRate != 8000 || wav.NumSamples != 8000 {
+			t.Fatalf("Unexpected header %+v", wav.WavHeader)
+		}
+		alias := 20 * math.Log10(magnitudeAt(wav.mono(), 2000, 8000)/(0.5*8000/2))
+		if antiAlias && alias > -40 {
+			t.Errorf("Expected the alias at 2 kHz to be at least 40 dB down, got %v dB", alias)
+		} else if !antiAlias && alias < -20 {
+			t.Errorf("Expected an alias at 2 kHz without filtering, got %v dB", alias)
+		}
+	}
+
+	// Tones below the new Nyquist frequency pass.
+	wav := testWav(44100, sineData(1000, 44100, 44100, 0.5))
+	wav.Resample(8000)
+	if level := 20 * math.Log10(magnitudeAt(wav.mono(), 1000, 8000)/(0.5*8000/2)); math.Abs(level) > 0.5 {
+		t.Errorf("Expected 1 kHz to pass unchanged, got %v dB", level)
+	}
+}
@@ -0,0 +1,91 @@
+package wav
+
+import (
+	"errors"
+	"io"
+	"math/cmplx"
+
+	"github.com/mjibson/go-dsp/fft"
+	"github.com/mjibson/go-dsp/window"
+)
+
+// StreamSpectrogram reads wav to its end, sending on the first channel
+// returned the magnitude spectrum, frameSize/2+1 bins, of each Hann windowed
+// frame of frameSize samples of its mono mix, frames starting hop samples
+// apart. As with spectral.Frames, the final frame is zero-padded if the
+// data ends before it is full. Columns are sent as soon as enough samples
+// have been read, so only about a frame of audio is held in memory. Once wav
+// is exhausted or an error occurs both channels are closed; an error is sent
+// on the second channel before it is closed.
+func StreamSpectrogram(wav *StreamedWav, frameSize, hop int) (<-chan []float64, <-chan error) {
+	columns := make(chan []float64)
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(columns)
+		defer close(errc)
+
+		if frameSize <= 0 || hop <= 0 {
+			errc <- errors.New("wav: invalid spectrogram frame size or hop")
+			return
+		}
+
+		w := &Wav{WavHeader: wav.WavHeader}
+		win := window.Hann(frameSize)
+		send := func(buf []float64) {
+			frame := make([]float64, frameSize)
+			for i := range buf {
+				frame[i] = buf[i] * win[i]
+			}
+			column := make([]float64, frameSize/2+1)
+			for k, v := range fft.FFTReal(frame)[:len(column)] {
+				column[k] = cmplx.Abs(v)
+			}
+			columns <- column
+		}
+
+		// buf starts at the next frame, and skip counts the samples still to
+		// pass over before it when hop is larger than frameSize.
+		var buf []float64
+		var skip int
+		var sent bool
+		for {
+			block, err := wav.readFullSamples(pipeBlock)
+			for _, sample := range block {
+				if skip > 0 {
+					skip--
+					continue
+				}
+				var v float64
+				for _, s := range sample {
+					v += w.normalize(s)
+				}
+				buf = append(buf, v/float64(len(sample)))
+			}
+
+			for len(buf) >= frameSize {
+				send(buf[:frameSize])
+				sent = true
+				n := min(hop, len(buf))
+				buf, skip = buf[n:], hop-n
+			}
+			// Keep only the samples still to be framed, so buf does not grow
+			// with the length of the stream.
+			buf = append([]float64(nil), buf...)
+
+			if err == io.EOF {
+				// Pad a last frame unless the previous one reached the end.
+				if len(buf) > 0 && (!sent || len(buf) > frameSize-hop) {
+					send(buf)
+				}
+				return
+			}
+			if err != nil {
+				errc <- err
+				return
+			}
+		}
+	}()
+
+	return columns, errc
+}
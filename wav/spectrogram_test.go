@@ -0,0 +1,65 @@
+package wav
+
+import (
+	"bytes"
+	"testing"
+	"testing/iotest"
+)
+
+func TestStreamSpectrogram(t *testing.T) {
+	// 1 kHz falls exactly on bin 32 of a 256 point frame at 8 kHz.
+	const frameSize, hop, n = 256, 64, 8000
+	var data bytes.Buffer
+	for _, sample := range sineData(1000, 8000, n, 0.5) {
+		write(&data, int16(sample[0]))
+	}
+	// Read a byte at a time, as from a pipe returning short reads.
+	file := riff(fmtChunk(1, 8000, 16), chunk("data", data.Bytes()))
+	wav, err := StreamWav(iotest.OneByteReader(bytes.NewReader(file)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns, errc := StreamSpectrogram(wav, frameSize, hop)
+	var count int
+	for column := range columns {
+		if len(column) != frameSize/2+1 {
+			t.Fatalf("Expected %d bins, got %d", frameSize/2+1, len(column))
+		}
+		peak := 0
+		for k, v := range column {
+			if v > column[peak] {
+				peak = k
+			}
+		}
+		if peak != 32 {
+			t.Fatalf("Column %d peaks at bin %d, expected 32", count, peak)
+		}
+		count++
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("StreamSpectrogram returned an error: %v", err)
+	}
+	if expected := (n-frameSize)/hop + 1; count != expected {
+		t.Fatalf("Expected %d columns, got %d", expected, count)
+	}
+
+	// Samples past the last full frame are framed with zero padding.
+	for _, extra := range []int{1, hop} {
+		file := riff(fmtChunk(1, 8000, 16), chunk("data", append(data.Bytes(), make([]byte, 2*extra)...)))
+		if wav, err = StreamWav(bytes.NewReader(file)); err != nil {
+			t.Fatal(err)
+		}
+		columns, errc := StreamSpectrogram(wav, frameSize, hop)
+		count = 0
+		for range columns {
+			count++
+		}
+		if err := <-errc; err != nil {
+			t.Fatalf("StreamSpectrogram returned an error: %v", err)
+		}
+		if expected := (n-frameSize)/hop + 2; count != expected {
+			t.Fatalf("%d extra samples: expected %d columns, got %d", extra, expected, count)
+		}
+	}
+}
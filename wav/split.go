@@ -0,0 +1,60 @@
+package wav
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// SplitOnSilence splits w into the clips between its silent gaps: runs of
+// at least minSilence in which every channel stays below thresholdDB,
+// relative to full scale. Silence at either end and within the gaps is
+// dropped. Shorter quiet runs, such as the zero crossings of a tone, do not
+// split a clip.
+func (w *Wav) SplitOnSilence(thresholdDB float64, minSilence time.Duration) ([]*Wav, error) {
+	minRun := samplesIn(minSilence, w.SampleRate)
+	if minRun < 1 {
+		return nil, errors.New("wav: minimum silence shorter than a sample")
+	}
+	threshold := math.Pow(10, thresholdDB/20)
+	loud := func(i int) bool {
+		for _, v := range w.Data[i] {
+			if math.Abs(w.normalize(v)) >= threshold {
+				return true
+			}
+		}
+		return false
+	}
+
+	var clips []*Wav
+	start, quiet := -1, 0
+	for i := range w.Data {
+		if loud(i) {
+			if start < 0 {
+				start = i
+			}
+			quiet = 0
+			continue
+		}
+		quiet++
+		if start >= 0 && quiet == minRun {
+			clips = append(clips, w.slice(start, i+1-quiet))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		clips = append(clips, w.slice(start, len(w.Data)-quiet))
+	}
+	return clips, nil
+}
+
+// slice returns a Wav holding a copy of samples start to end of w.
+func (w *Wav) slice(start, end int) *Wav {
+	data := make([][]int, end-start)
+	for i := range data {
+		data[i] = append([]int(nil), w.Data[start+i]...)
+	}
+	header := w.WavHeader
+	header.Peaks = nil
+	return newWav(header, data)
+}
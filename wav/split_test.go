@@ -0,0 +1,43 @@
+package wav
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplitOnSilence(t *testing.T) {
+	// Silence, 0.5 s of tone, 0.5 s of silence, 0.25 s of tone, silence.
+	var data [][]int
+	silence := func(n int) {
+		for i := 0; i < n; i++ {
+			data = append(data, []int{0})
+		}
+	}
+	silence(800)
+	data = append(data, sineData(440, 8000, 4000, 0.5)...)
+	silence(4000)
+	data = append(data, sineData(880, 8000, 2000, 0.5)...)
+	silence(100)
+	wav := testWav(8000, data)
+
+	clips, err := wav.SplitOnSilence(-40, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SplitOnSilence returned an error: %v", err)
+	}
+	if len(clips) != 2 {
+		t.Fatalf("Expected 2 clips, got %d", len(clips))
+	}
+	// Each tone starts and ends at a zero crossing, which counts as silence.
+	for i, expected := range []int{4000, 2000} {
+		if n := len(clips[i].Data); n < expected-2 || n > expected {
+			t.Errorf("Clip %d: expected about %d samples, got %d", i, expected, n)
+		}
+		if clips[i].NumSamples != len(clips[i].Data) || clips[i].SampleRate != 8000 {
+			t.Errorf("Clip %d: unexpected header %+v", i, clips[i].WavHeader)
+		}
+	}
+
+	if _, err = wav.SplitOnSilence(-40, 0); err == nil {
+		t.Fatal("Expected an error for a zero minimum silence")
+	}
+}
@@ -0,0 +1,103 @@
+package wav
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"github.com/mjibson/go-dsp/fft"
+	"github.com/mjibson/go-dsp/window"
+)
+
+const (
+	// pvFrame is the STFT frame size of the phase vocoder.
+	pvFrame = 2048
+	// pvHop is the synthesis hop of the phase vocoder, a quarter frame.
+	pvHop = pvFrame / 4
+)
+
+// TimeStretchPV returns a copy of w stretched in time by factor, e.g. 2 for
+// twice as long, without changing its pitch. It uses a phase vocoder: each
+// frequency bin of the short-time spectrum keeps its magnitude while its
+// phase is advanced at the bin's measured frequency, so partials stay
+// continuous across frames.
+func (w *Wav) TimeStretchPV(factor float64) (*Wav, error) {
+	if !(factor > 0) || math.IsInf(factor, 0) {
+		return nil, errors.New("wav: invalid time stretch factor")
+	}
+
+	n := int(math.Round(float64(len(w.Data)) * factor))
+	data := make([][]int, n)
+	for i := range data {
+		data[i] = make([]int, w.NumChannels)
+	}
+	for ch := 0; ch < int(w.NumChannels); ch++ {
+		for i, v := range phaseVocoder(w.channel(ch), factor, n) {
+			data[i][ch] = w.denormalize(v)
+		}
+	}
+
+	header := w.WavHeader
+	header.Peaks = nil
+	return newWav(header, data), nil
+}
+
+// phaseVocoder returns n samples of x stretched in time by factor.
+func phaseVocoder(x []float64, factor float64, n int) []float64 {
+	win := window.Hann(pvFrame)
+	analysisHop := pvHop / factor
+	bins := pvFrame/2 + 1
+
+	y := make([]float64, n+pvFrame)
+	norm := make([]float64, n+pvFrame)
+	prevPhase := make([]float64, bins)
+	phase := make([]float64, bins)
+	frame := make([]float64, pvFrame)
+	spectrum := make([]complex128, pvFrame)
+
+	for m := 0; m*pvHop < n; m++ {
+		// Frames are centred on their positions so the first covers the start.
+		start := int(math.Round(float64(m)*analysisHop)) - pvFrame/2
+		for i := range frame {
+			frame[i] = 0
+			if j := start + i; j >= 0 && j < len(x) {
+				frame[i] = x[j] * win[i]
+			}
+		}
+
+		X := fft.FFTReal(frame)
+		for k := 0; k < bins; k++ {
+			mag, p := cmplx.Abs(X[k]), cmplx.Phase(X[k])
+			if m == 0 {
+				phase[k] = p
+			} else {
+				omega := 2 * math.Pi * float64(k) / pvFrame
+				delta := p - prevPhase[k] - omega*analysisHop
+				delta -= 2 * math.Pi * math.Round(delta/(2*math.Pi))
+				phase[k] += (omega + delta/analysisHop) * pvHop
+			}
+			prevPhase[k] = p
+			spectrum[k] = cmplx.Rect(mag, phase[k])
+			if k > 0 && k < pvFrame/2 {
+				spectrum[pvFrame-k] = cmplx.Conj(spectrum[k])
+			}
+		}
+
+		out := m*pvHop - pvFrame/2
+		for i, v := range fft.IFFT(spectrum) {
+			if j := out + i + pvFrame/2; j >= 0 && j < len(y) {
+				y[j] += real(v) * win[i]
+				norm[j] += win[i] * win[i]
+			}
+		}
+	}
+
+	// Undo the gain of the overlapping analysis and synthesis windows.
+	result := make([]float64, n)
+	for i := range result {
+		if j := i + pvFrame/2; norm[j] > 1e-6 {
+			result[i] = y[j] / norm[j]
+		}
+	}
+	return result
+}
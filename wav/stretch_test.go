@@ -0,0 +1,75 @@
+package wav
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/mjibson/go-dsp/fft"
+	"github.com/mjibson/go-dsp/window"
+)
+
+// olaStretch stretches x by factor with plain overlap-add of Hann windowed
+// frames, the baseline a phase vocoder improves on.
+func olaStretch(x []float64, factor float64) []float64 {
+	const frame, hop = 1024, 256
+	win := window.Hann(frame)
+	n := int(math.Round(float64(len(x)) * factor))
+	y := make([]float64, n+frame)
+	norm := make([]float64, n+frame)
+	for out := 0; out < n; out += hop {
+		in := int(float64(out) / factor)
+		for i := 0; i < frame && in+i < len(x); i++ {
+			y[out+i] += x[in+i] * win[i] * win[i]
+			norm[out+i] += win[i] * win[i]
+		}
+	}
+	for i := range y {
+		if norm[i] > 1e-6 {
+			y[i] /= norm[i]
+		}
+	}
+	return y[:n]
+}
+
+// tonePurity returns the fraction of the power of x within 2% of freq.
+func tonePurity(x []float64, freq, sampleRate float64) float64 {
+	x = append([]float64(nil), x...)
+	window.Apply(x, window.Hann)
+	X := fft.FFTReal(x)
+	var in, total float64
+	for k := 1; k < len(X)/2; k++ {
+		p := math.Pow(cmplx.Abs(X[k]), 2)
+		total += p
+		if f := float64(k) * sampleRate / float64(len(X)); math.Abs(f-freq) < 0.02*freq {
+			in += p
+		}
+	}
+	return in / total
+}
+
+func TestTimeStretchPV(t *testing.T) {
+	wav := testWav(16000, sineData(440, 16000, 16000, 0.5))
+	stretched, err := wav.TimeStretchPV(1.5)
+	if err != nil {
+		t.Fatalf("TimeStretchPV returned an error: %v", err)
+	}
+	if len(stretched.Data) != 24000 || stretched.NumSamples != 24000 || stretched.SampleRate != 16000 {
+		t.Fatalf("Expected 24000 samples at 16000 Hz, got %d at %d Hz", len(stretched.Data), stretched.SampleRate)
+	}
+	if f, err := EstimateFundamental(stretched); err != nil || math.Abs(f-440) > 3 {
+		t.Fatalf("Expected the pitch to stay at 440 Hz, got %v (%v)", f, err)
+	}
+
+	// Compare the steady middle of the outputs.
+	pv := stretched.channel(0)[4000:20000]
+	ola := olaStretch(wav.channel(0), 1.5)[4000:20000]
+	pvPurity, olaPurity := tonePurity(pv, 440, 16000), tonePurity(ola, 440, 16000)
+	if pvPurity < 0.99 || pvPurity <= olaPurity {
+		t.Fatalf("Expected the phase vocoder to be purer than overlap-add, got %v and %v", pvPurity, olaPurity)
+	}
+
+	if _, err = wav.TimeStretchPV(0); err == nil {
+		t.Fatal("Expected an error for a zero factor")
+	}
+}
@@ -0,0 +1,140 @@
+package wav
+
+import (
+	"errors"
+	"math"
+	"math/cmplx"
+
+	"github.com/mjibson/go-dsp/fft"
+	"github.com/mjibson/go-dsp/spectral"
+	"github.com/mjibson/go-dsp/window"
+)
+
+// Tempo range, in beats per minute, EstimateBPM searches.
+const (
+	minBPM = 60
+	maxBPM = 200
+)
+
+// tempoFrameRate is the number of onset envelope values per second
+// EstimateBPM computes.
+const tempoFrameRate = 100
+
+// EstimateBPM estimates the tempo of w in beats per minute, between 60 and
+// 200. The rises in its ShortTimeEnergy form an onset envelope, whose
+// strongest autocorrelation peak gives the beat period.
+func EstimateBPM(w *Wav) (float64, error) {
+	hop := int(w.SampleRate) / tempoFrameRate
+	if hop == 0 || len(w.Data) == 0 {
+		return 0, errors.New("wav: no data to estimate a tempo from")
+	}
+	frameRate := float64(w.SampleRate) / float64(hop)
+	energy := w.ShortTimeEnergy(2*hop, hop)
+
+	// Keep only increases in energy, centred so that the autocorrelation
+	// of steady parts is not positive at every lag.
+	onsets := make([]float64, len(energy))
+	var mean float64
+	for i := 1; i < len(energy); i++ {
+		if d := energy[i] - energy[i-1]; d > 0 {
+			onsets[i] = d
+		}
+		mean += onsets[i]
+	}
+	mean /= float64(len(onsets))
+	for i := range onsets {
+		onsets[i] -= mean
+	}
+
+	lo := int(frameRate * 60 / maxBPM)
+	hi := int(frameRate*60/minBPM) + 1
+	if hi >= len(onsets)-1 {
+		return 0, errors.New("wav: too short to estimate a tempo")
+	}
+	r := Autocorrelation(onsets, hi+1)
+	if r == nil {
+		return 0, errors.New("wav: signal has no onsets")
+	}
+
+	best := 0
+	for lag := lo; lag <= hi; lag++ {
+		if r[lag] > r[lag-1] && r[lag] >= r[lag+1] && (best == 0 || r[lag] > r[best]) {
+			best = lag
+		}
+	}
+	if best == 0 {
+		return 0, errors.New("wav: no beat found")
+	}
+
+	// Parabolic interpolation around the peak for sub-frame accuracy.
+	period := float64(best)
+	if d := r[best-1] - 2*r[best] + r[best+1]; d != 0 {
+		period += 0.5 * (r[best-1] - r[best+1]) / d
+	}
+	return 60 * frameRate / period, nil
+}
+
+// Frame length and hop, in samples, of the spectra DetectOnsets compares.
+const (
+	onsetFrame = 1024
+	onsetHop   = 256
+)
+
+// minOnsetGap is the shortest time, in seconds, between two onsets
+// DetectOnsets reports.
+const minOnsetGap = 0.05
+
+// DetectOnsets returns the sample indices of the onsets of notes and beats
+// in the mono signal of w, found as peaks in its spectral flux: the summed
+// increase in magnitude of each frequency from one frame to the next.
+// sensitivity, from 0 to 1, sets how strong a peak must be relative to the
+// strongest to count; 0.5 reports peaks at least half as strong.
+func (w *Wav) DetectOnsets(sensitivity float64) []int {
+	hann := window.Hann(onsetFrame)
+	// Frames are centred on multiples of the hop, and the first is compared
+	// against silence, so that a sound at the very start counts.
+	x := append(make([]float64, onsetFrame/2), w.mono()...)
+	prev := make([]float64, onsetFrame/2+1)
+	var flux []float64
+	spectral.Frames(x, onsetFrame, onsetHop, func(frame []float64) {
+		windowed := make([]float64, len(frame))
+		for i, v := range frame {
+			windowed[i] = v * hann[i]
+		}
+		spectrum := fft.FFTReal(windowed)[:onsetFrame/2+1]
+		mag := make([]float64, len(spectrum))
+		var sum float64
+		for k, c := range spectrum {
+			mag[k] = cmplx.Abs(c)
+			sum += math.Max(0, mag[k]-prev[k])
+		}
+		flux = append(flux, sum)
+		prev = mag
+	})
+
+	var peak float64
+	for _, f := range flux {
+		peak = math.Max(peak, f)
+	}
+	if peak == 0 {
+		return nil
+	}
+	threshold := (1 - sensitivity) * peak
+	gap := int(minOnsetGap * float64(w.SampleRate))
+
+	var onsets []int
+	for i, f := range flux {
+		if (i > 0 && f <= flux[i-1]) || (i+1 < len(flux) && f < flux[i+1]) || f < threshold {
+			continue
+		}
+		pos := i * onsetHop
+		if pos >= len(w.Data) {
+			break
+		}
+		if len(onsets) > 0 && pos-onsets[len(onsets)-1] < gap {
+			continue
+		}
+		onsets = append(onsets, pos)
+	}
+	return onsets
+}
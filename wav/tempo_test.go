@@ -0,0 +1,58 @@
+package wav
+
+import (
+	"math"
+	"testing"
+)
+
+// clickTrack returns seconds of mono 16-bit data at sampleRate holding a
+// short decaying 1 kHz click every interval samples.
+func clickTrack(sampleRate uint32, seconds float64, interval int) [][]int {
+	data := make([][]int, int(seconds*float64(sampleRate)))
+	for i := range data {
+		data[i] = []int{0}
+	}
+	for start := 0; start < len(data); start += interval {
+		for i := 0; i < int(sampleRate)/100 && start+i < len(data); i++ {
+			ti := float64(i) / float64(sampleRate)
+			data[start+i][0] = int(20000 * math.Exp(-ti*500) * math.Sin(2*math.Pi*1000*ti))
+		}
+	}
+	return data
+}
+
+func TestEstimateBPM(t *testing.T) {
+	for _, bpm := range []float64{120, 90, 150} {
+		interval := int(44100 * 60 / bpm)
+		wav := testWav(44100, clickTrack(44100, 10, interval))
+		got, err := EstimateBPM(wav)
+		if err != nil {
+			t.Fatalf("EstimateBPM returned an error: %v", err)
+		}
+		if math.Abs(got-bpm) > 1 {
+			t.Errorf("Expected ~%v BPM, got %v", bpm, got)
+		}
+	}
+
+	if _, err := EstimateBPM(testWav(44100, sineData(0, 44100, 44100*5, 0))); err == nil {
+		t.Fatal("Expected an error for silence")
+	}
+}
+
+func TestDetectOnsets(t *testing.T) {
+	const interval = 11025
+	wav := testWav(44100, clickTrack(44100, 2, interval))
+	onsets := wav.DetectOnsets(0.5)
+	if len(onsets) != 8 {
+		t.Fatalf("Expected 8 onsets, got %v", onsets)
+	}
+	for i, pos := range onsets {
+		if d := pos - i*interval; d < -onsetHop || d > onsetHop {
+			t.Errorf("Onset %d at sample %d, expected near %d", i, pos, i*interval)
+		}
+	}
+
+	if onsets := testWav(44100, sineData(0, 44100, 44100, 0)).DetectOnsets(1); onsets != nil {
+		t.Fatalf("Expected no onsets in silence, got %v", onsets)
+	}
+}
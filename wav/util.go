@@ -1,8 +1,17 @@
 package wav
 
 import (
+	"bytes"
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"strconv"
 )
 
 func (w *Wav) GetMonoData() []float64 {
@@ -20,6 +29,87 @@ func (w *Wav) GetMonoData() []float64 {
 }
 
 func WriteMono(filename string, data []float64, sampleRate uint32) error {
+	ofile, oerr := os.Create(filename)
+	if oerr != nil {
+		return oerr
+	}
+	defer ofile.Close()
+
+	return writeMono(ofile, data, sampleRate)
+}
+
+// WriteMonoAtomic is like WriteMono, but writes to a temporary file in the
+// same directory and renames it to filename once complete, so filename never
+// holds a partially written file.
+func WriteMonoAtomic(filename string, data []float64, sampleRate uint32) error {
+	return writeFileAtomic(filename, func(w io.Writer) error {
+		return writeMono(w, data, sampleRate)
+	})
+}
+
+// Save writes w to the file at path, creating or truncating it.
+func (w *Wav) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = w.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// SaveAs writes w to the file at path converted to bits per sample, as by
+// ConvertBitDepth. If dither is true and bits is lower than BitsPerSample,
+// the noise is drawn from a fixed seed, so that saving the same Wav twice
+// gives the same file. w itself is unchanged.
+func (w *Wav) SaveAs(path string, bits uint16, dither bool) error {
+	var rng *rand.Rand
+	if dither && bits < w.BitsPerSample {
+		rng = rand.New(rand.NewSource(1))
+	}
+	converted, err := w.ConvertBitDepth(bits, rng)
+	if err != nil {
+		return err
+	}
+	return converted.Save(path)
+}
+
+// ConvertBitDepth returns a copy of w with bits per sample, one of 8, 16, 24
+// or 32. Samples are rescaled to the same level relative to full scale.
+// Reducing the depth rounds off the low bits; if dither is not nil,
+// triangular noise of one step at the new depth is drawn from it and added
+// first, which decorrelates the rounding error from the signal.
+func (w *Wav) ConvertBitDepth(bits uint16, dither *rand.Rand) (*Wav, error) {
+	if bits != 8 && bits != 16 && bits != 24 && bits != 32 {
+		return nil, newError(ErrUnsupportedFormat, "wav: unsupported bits per sample")
+	}
+
+	header := w.WavHeader
+	header.AudioFormat = formatPCM
+	header.BitsPerSample = bits
+	header.ValidBitsPerSample = 0
+	converted := &Wav{WavHeader: header}
+	step := 1 / float64(int64(1)<<(bits-1))
+
+	data := make([][]int, len(w.Data))
+	for i, sample := range w.Data {
+		data[i] = make([]int, len(sample))
+		for ch, v := range sample {
+			x := w.normalize(v)
+			if dither != nil {
+				x += (dither.Float64() - dither.Float64()) * step
+			}
+			data[i][ch] = converted.denormalize(x)
+		}
+	}
+	converted = newWav(header, data)
+	converted.RawChunks = w.RawChunks
+	return converted, nil
+}
+
+func writeMono(w io.Writer, data []float64, sampleRate uint32) error {
 	bitsPerSample := 16
 	channels := 1
 
@@ -33,19 +123,241 @@ func WriteMono(filename string, data []float64, sampleRate uint32) error {
 	bytes := make([]byte, 2*len(data))
 	for i, val := range data {
 		start := i * 2
-		binary.LittleEndian.PutUint16(bytes[start:start+2], uint16(val))
+		v := math.Max(math.MinInt16, math.Min(math.MaxInt16, math.Round(val)))
+		binary.LittleEndian.PutUint16(bytes[start:start+2], uint16(int16(v)))
 	}
 
-	ofile, oerr := os.Create(filename)
-	if oerr != nil {
-		return oerr
+	return outFile.WriteData(w, bytes)
+}
+
+// WriteInt16 writes data, interleaved 16-bit samples of the given number of
+// channels, to w as a wav file.
+func WriteInt16(w io.Writer, data []int16, sampleRate uint32, channels uint16) error {
+	if channels == 0 || len(data)%int(channels) != 0 {
+		return errors.New("wav: data is not a whole number of samples")
+	}
+
+	bytes := make([]byte, 2*len(data))
+	for i, val := range data {
+		binary.LittleEndian.PutUint16(bytes[2*i:], uint16(val))
+	}
+
+	outFile := &File{sampleRate, 16, channels}
+	return outFile.WriteData(w, bytes)
+}
+
+// WriteFloat32 writes data, interleaved samples of the given number of
+// channels, to w as a 32-bit IEEE float wav file. The values are stored as
+// they are, with full scale at ±1 and nothing clipped.
+func WriteFloat32(w io.Writer, data []float32, sampleRate uint32, channels uint16) (err error) {
+	defer func() {
+		if e, ok := recover().(error); ok {
+			err = e
+		}
+	}()
+	if channels == 0 || len(data)%int(channels) != 0 {
+		return errors.New("wav: data is not a whole number of samples")
+	}
+
+	header := WavHeader{
+		AudioFormat:   formatIEEEFloat,
+		NumChannels:   channels,
+		SampleRate:    sampleRate,
+		BitsPerSample: 32,
+		NumSamples:    len(data) / int(channels),
+	}
+	header.Recompute()
+
+	var buf bytes.Buffer
+	writeFmtHeader(&buf, header)
+	// Formats other than PCM carry the number of samples in a 'fact' chunk.
+	var fact bytes.Buffer
+	write(&fact, uint32(header.NumSamples))
+	writeChunk(&buf, "fact", fact.Bytes())
+	var samples bytes.Buffer
+	write(&samples, data)
+	writeChunk(&buf, "data", samples.Bytes())
+	writeRIFF(w, buf.Bytes())
+	return nil
+}
+
+// ReadFloat32 reads a 32-bit IEEE float wav file from r, as written by
+// WriteFloat32, and returns its interleaved samples exactly as stored along
+// with its header, including any PEAK chunk.
+func ReadFloat32(r io.Reader) (data []float32, header WavHeader, err error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, header, err
+	}
+	var wav Wav
+	raw, err := wav.parseChunks(b)
+	if err != nil {
+		return nil, header, err
+	}
+	if wav.AudioFormat != formatIEEEFloat || wav.BitsPerSample != 32 {
+		return nil, header, newError(ErrUnsupportedFormat, "wav: not a 32-bit float wav file")
+	}
+	if int64(len(raw)) < int64(wav.ChunkSize) {
+		return nil, header, newError(ErrTruncated, "wav: data chunk runs past the end of the file")
 	}
 
-	err := outFile.WriteData(ofile, bytes)
+	data = make([]float32, wav.NumSamples*int(wav.NumChannels))
+	for i := range data {
+		data[i] = math.Float32frombits(bLEtoUint32(raw, 4*i))
+	}
+	return data, wav.WavHeader, nil
+}
 
+// writeFileAtomic calls writeTo with a temporary file next to filename and
+// renames it to filename if writeTo succeeds. On failure the temporary file is
+// removed and filename is left untouched. The file gets the mode os.Create
+// would give it: that of an existing filename, or 0666 less the umask.
+func writeFileAtomic(filename string, writeTo func(io.Writer) error) (err error) {
+	f, err := createTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp")
 	if err != nil {
 		return err
 	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
 
-	return nil
+	if err = writeTo(f); err != nil {
+		return err
+	}
+	if info, statErr := os.Stat(filename); statErr == nil {
+		if err = f.Chmod(info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
+
+// createTemp creates a new file in dir whose name begins with prefix, with
+// mode 0666 less the umask, unlike ioutil.TempFile which uses 0600.
+func createTemp(dir, prefix string) (*os.File, error) {
+	for i := 0; ; i++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && i < 10000 {
+			continue
+		}
+		return f, err
+	}
+}
+
+// normalize scales the sample value v to [-1, 1) according to BitsPerSample.
+func (w *Wav) normalize(v int) float64 {
+	return PCMToFloat(v, w.BitsPerSample)
+}
+
+// denormalize is the inverse of normalize.
+func (w *Wav) denormalize(x float64) int {
+	return FloatToPCM(x, w.BitsPerSample)
+}
+
+// PCMToFloat scales the sample value v of the given bits per sample to
+// [-1, 1), full scale being 2^(bits-1). 8-bit samples are unsigned, with 128
+// as 0; all wider ones are signed. bits is usually 8, 16, 24 or 32, and must
+// be from 1 to 64; PCMToFloat panics otherwise.
+func PCMToFloat(v int, bits uint16) float64 {
+	checkBits(bits)
+	if bits == 8 {
+		return float64(v-128) / 128
+	}
+	return float64(v) / math.Ldexp(1, int(bits)-1)
+}
+
+// FloatToPCM is the inverse of PCMToFloat, rounding v to the nearest sample
+// value and clamping it to the range of bits per sample. Like PCMToFloat it
+// panics if bits is not from 1 to 64.
+func FloatToPCM(v float64, bits uint16) int {
+	checkBits(bits)
+	scale := math.Ldexp(1, int(bits)-1)
+	hi := int64(1)<<(bits-1) - 1
+	x := hi
+	if r := math.Round(v * scale); r < -scale {
+		x = -hi - 1
+	} else if r < scale {
+		x = int64(r)
+	}
+	if bits == 8 {
+		return int(x) + 128
+	}
+	return int(x)
+}
+
+// checkBits panics if bits is not a sample width PCMToFloat and FloatToPCM
+// can scale by.
+func checkBits(bits uint16) {
+	if bits == 0 || bits > 64 {
+		panic(fmt.Sprintf("wav: %d bits per sample is out of range", bits))
+	}
+}
+
+// QuantizeFloat converts data, mono samples normalized to [-1, 1), to
+// sample values of the given bits per sample, rounding to the nearest value
+// and clamping to its range. The result is indexed like Data, ready for a
+// Wav of one channel; 8-bit values are unsigned.
+func QuantizeFloat(data []float64, bits uint16) [][]int {
+	y := make([][]int, len(data))
+	for i, x := range data {
+		y[i] = []int{FloatToPCM(x, bits)}
+	}
+	return y
+}
+
+// mono returns the normalized average of all channels of w.
+func (w *Wav) mono() []float64 {
+	y := make([]float64, len(w.Data))
+	for i, sample := range w.Data {
+		for _, v := range sample {
+			y[i] += w.normalize(v)
+		}
+		y[i] /= float64(len(sample))
+	}
+	return y
+}
+
+// Integer is the set of integer types Samples can convert to. It mirrors
+// constraints.Integer from golang.org/x/exp without the dependency.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Samples returns a copy of w.Data converted to T, indexed by sample.
+func Samples[T Integer](w *Wav) [][]T {
+	y := make([][]T, len(w.Data))
+	for i, sample := range w.Data {
+		y[i] = make([]T, len(sample))
+		for ch, v := range sample {
+			y[i][ch] = T(v)
+		}
+	}
+	return y
+}
+
+// channel returns the normalized samples of channel ch of w.
+func (w *Wav) channel(ch int) []float64 {
+	y := make([]float64, len(w.Data))
+	for i, sample := range w.Data {
+		y[i] = w.normalize(sample[ch])
+	}
+	return y
+}
+
+// setChannel quantizes x and stores it as channel ch of w.
+func (w *Wav) setChannel(ch int, x []float64) {
+	for i, v := range x {
+		w.set(i, ch, w.denormalize(v))
+	}
 }
@@ -0,0 +1,294 @@
+package wav
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteMonoAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "out.wav")
+
+	err = writeFileAtomic(filename, func(w io.Writer) error {
+		if _, err := w.Write([]byte("RIFF")); err != nil {
+			return err
+		}
+		return errors.New("write failed")
+	})
+	if err == nil {
+		t.Fatal("Expected the failed write to return an error")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatalf("Expected a failed write to leave no files, found %d", len(files))
+	}
+
+	if err = WriteMonoAtomic(filename, []float64{0, 1000, -1000}, 8000); err != nil {
+		t.Fatalf("WriteMonoAtomic returned an error: %v", err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Fatalf("Expected only the destination file, found %d files", len(files))
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	wav, err := ReadWav(f)
+	if err != nil {
+		t.Fatalf("Unable to read back the written file: %v", err)
+	}
+	if wav.NumSamples != 3 || wav.Data[2][0] != -1000 {
+		t.Fatalf("Unexpected data read back: %v", wav.Data)
+	}
+
+	// New files get the same mode as from WriteMono, and existing files
+	// keep theirs.
+	plain := filepath.Join(dir, "plain.wav")
+	if err = WriteMono(plain, []float64{0}, 8000); err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := os.Stat(plain)
+	atomic := filepath.Join(dir, "atomic.wav")
+	if err = WriteMonoAtomic(atomic, []float64{0}, 8000); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(atomic); info.Mode() != expected.Mode() {
+		t.Fatalf("Expected mode %v, got %v", expected.Mode(), info.Mode())
+	}
+	if err = os.Chmod(atomic, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err = WriteMonoAtomic(atomic, []float64{0}, 8000); err != nil {
+		t.Fatal(err)
+	}
+	if info, _ := os.Stat(atomic); info.Mode().Perm() != 0640 {
+		t.Fatalf("Expected the existing mode 0640 to be kept, got %v", info.Mode())
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "out.wav")
+
+	wav := newWav(WavHeader{AudioFormat: 1, NumChannels: 2, SampleRate: 44100, BitsPerSample: 16},
+		[][]int{{1, -1}, {1000, -1000}, {32767, -32768}})
+	if err = wav.Pan(-0.5); err != nil {
+		t.Fatal(err)
+	}
+	if err = wav.Save(filename); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	saved, err := ReadWav(f)
+	if err != nil {
+		t.Fatalf("Unable to read back the saved file: %v", err)
+	}
+	compareWavs(t, wav, saved)
+}
+
+func TestWriteInt16(t *testing.T) {
+	data := []int16{-32768, 32767, 0, -1, 1234, -4321}
+	var buf bytes.Buffer
+	if err := WriteInt16(&buf, data, 22050, 2); err != nil {
+		t.Fatalf("WriteInt16 returned an error: %v", err)
+	}
+	wav, err := ReadWav(&buf)
+	if err != nil {
+		t.Fatalf("Unable to read back the written data: %v", err)
+	}
+	if wav.NumChannels != 2 || wav.SampleRate != 22050 || wav.NumSamples != 3 {
+		t.Fatalf("Unexpected header %+v", wav.WavHeader)
+	}
+	for i, v := range data {
+		if got := wav.Data16[i/2][i%2]; got != v {
+			t.Fatalf("Sample %d channel %d differs. Expected %d. Got %d", i/2, i%2, v, got)
+		}
+	}
+
+	if err = WriteInt16(&buf, data[:5], 22050, 2); err == nil {
+		t.Fatal("Expected an error for data that is not a whole number of samples")
+	}
+}
+
+func TestQuantizeFloat(t *testing.T) {
+	step := 1.0 / 32768
+	data := []float64{1.5 * step, 2.5 * step, 2.49 * step, -1.5 * step, -2.51 * step, 2, -2}
+	expected := []int{2, 3, 2, -2, -3, 32767, -32768}
+	got := QuantizeFloat(data, 16)
+	for i := range expected {
+		if got[i][0] != expected[i] {
+			t.Errorf("Value %v: expected %d, got %d", data[i], expected[i], got[i][0])
+		}
+	}
+
+	if got = QuantizeFloat([]float64{-1, 0.5 / 128, 1}, 8); got[0][0] != 0 || got[1][0] != 129 || got[2][0] != 255 {
+		t.Errorf("Unexpected 8-bit values %v", got)
+	}
+}
+
+func TestWriteMonoClamps(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeMono(&buf, []float64{1.5, -1.5, 40000, -40000}, 8000); err != nil {
+		t.Fatal(err)
+	}
+	wav, err := ReadWav(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{2, -2, 32767, -32768}
+	for i := range expected {
+		if wav.Data[i][0] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, wav.Data)
+		}
+	}
+}
+
+func TestSaveAs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wav := testWav(8000, sineData(440, 8000, 800, 0.5))
+	for _, bits := range []uint16{24, 8} {
+		filename := filepath.Join(dir, "out.wav")
+		if err = wav.SaveAs(filename, bits, true); err != nil {
+			t.Fatalf("SaveAs returned an error: %v", err)
+		}
+		saved, err := OpenWav(filename)
+		if err != nil {
+			t.Fatalf("Unable to read back the saved file: %v", err)
+		}
+		if saved.BitsPerSample != bits || saved.NumSamples != wav.NumSamples {
+			t.Fatalf("Unexpected header %+v", saved.WavHeader)
+		}
+
+		// Within one step at the lower depth, plus one for dither.
+		tolerance := 1 / float64(int(1)<<(min(bits, 16)-1))
+		if bits < 16 {
+			tolerance *= 2
+		}
+		for i := range wav.Data {
+			if d := math.Abs(saved.normalize(saved.Data[i][0]) - wav.normalize(wav.Data[i][0])); d > tolerance {
+				t.Fatalf("%d-bit sample %d differs by %v", bits, i, d)
+			}
+		}
+	}
+	if wav.BitsPerSample != 16 {
+		t.Fatal("Expected SaveAs to leave the Wav unchanged")
+	}
+
+	if err = wav.SaveAs(filepath.Join(dir, "bad.wav"), 12, true); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("Expected ErrUnsupportedFormat for 12 bits, got %v", err)
+	}
+
+	// Dithered output is reproducible, and without dither the samples are
+	// simply rounded.
+	a, b := filepath.Join(dir, "a.wav"), filepath.Join(dir, "b.wav")
+	for _, filename := range []string{a, b} {
+		if err = wav.SaveAs(filename, 8, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, _ := ioutil.ReadFile(a)
+	second, _ := ioutil.ReadFile(b)
+	if !bytes.Equal(first, second) {
+		t.Fatal("Expected dithered saves of the same Wav to match byte for byte")
+	}
+	if err = wav.SaveAs(a, 8, false); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := OpenWav(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range wav.Data {
+		if expected := FloatToPCM(wav.normalize(wav.Data[i][0]), 8); saved.Data[i][0] != expected {
+			t.Fatalf("Sample %d: expected %d without dither, got %d", i, expected, saved.Data[i][0])
+		}
+	}
+}
+
+func TestPCMToFloat(t *testing.T) {
+	for _, bits := range []uint16{8, 16, 24, 32} {
+		lo, hi := -(1 << (bits - 1)), 1<<(bits-1)-1
+		if bits == 8 {
+			lo, hi = 0, 255
+		}
+		if x := PCMToFloat(lo, bits); x != -1 {
+			t.Errorf("%d bits: expected %d to be -1, got %v", bits, lo, x)
+		}
+		if v := FloatToPCM(1, bits); v != hi {
+			t.Errorf("%d bits: expected 1 to clamp to %d, got %d", bits, hi, v)
+		}
+		if v := FloatToPCM(-2, bits); v != lo {
+			t.Errorf("%d bits: expected -2 to clamp to %d, got %d", bits, lo, v)
+		}
+		for _, v := range []int{lo, lo + 1, (lo + hi) / 2, (lo+hi)/2 + 1, hi - 1, hi} {
+			if got := FloatToPCM(PCMToFloat(v, bits), bits); got != v {
+				t.Errorf("%d bits: %d round-trips to %d", bits, v, got)
+			}
+		}
+	}
+
+	for _, bits := range []uint16{0, 65} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected %d bits per sample to panic", bits)
+				}
+			}()
+			FloatToPCM(0.5, bits)
+		}()
+	}
+}
+
+func TestWriteFloat32(t *testing.T) {
+	data := []float32{0, 1, -1, 0.5, 1e-30, -0.123456789, 1.5, float32(math.Inf(-1))}
+	var buf bytes.Buffer
+	if err := WriteFloat32(&buf, data, 48000, 2); err != nil {
+		t.Fatalf("WriteFloat32 returned an error: %v", err)
+	}
+	if riffSize := int(bLEtoUint32(buf.Bytes(), 4)); riffSize != buf.Len()-8 {
+		t.Fatalf("RIFF size is %d, expected %d", riffSize, buf.Len()-8)
+	}
+
+	got, header, err := ReadFloat32(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadFloat32 returned an error: %v", err)
+	}
+	if header.SampleRate != 48000 || header.NumChannels != 2 {
+		t.Fatalf("Expected 2 channels at 48000 Hz, got %d at %d Hz", header.NumChannels, header.SampleRate)
+	}
+	if !reflect.DeepEqual(got, data) {
+		t.Fatalf("Expected %v, got %v", data, got)
+	}
+
+	if _, _, err = ReadFloat32(bytes.NewReader(riff(fmtChunk(1, 8000, 16), chunk("data", nil)))); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("Expected ErrUnsupportedFormat reading PCM, got %v", err)
+	}
+	if err = WriteFloat32(&buf, data[:3], 48000, 2); err == nil {
+		t.Fatal("Expected an error for data that is not a whole number of samples")
+	}
+}
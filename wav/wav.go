@@ -18,9 +18,14 @@
 package wav
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
+	"os"
+	"time"
 )
 
 const (
@@ -39,6 +44,14 @@ const (
 	ExpectedHeaderSize  = 44
 )
 
+// Audio formats with special handling.
+const (
+	formatPCM        = 0x0001
+	formatIEEEFloat  = 0x0003
+	formatIMAADPCM   = 0x0011
+	formatExtensible = 0xfffe
+)
+
 type WavHeader struct {
 	AudioFormat   uint16
 	NumChannels   uint16
@@ -48,6 +61,33 @@ type WavHeader struct {
 	BitsPerSample uint16
 	ChunkSize     uint32
 	NumSamples    int
+
+	// RIFFSize is the size declared in the RIFF header, which should be the
+	// length of the file less 8 bytes.
+	RIFFSize uint32
+
+	// ValidBitsPerSample is the number of bits of each BitsPerSample wide
+	// container that hold the sample, if the fmt chunk has the
+	// WAVE_FORMAT_EXTENSIBLE extension. Otherwise it is 0.
+	ValidBitsPerSample uint16
+
+	// ChannelMask is the speaker position bitmask of the
+	// WAVE_FORMAT_EXTENSIBLE extension, such as 0x3f for 5.1, or 0 without
+	// one. Bit 0 is front left; ChannelLayout names them all.
+	ChannelMask uint32
+
+	// PartialBytes is the number of bytes at the end of the data chunk that
+	// do not make up a whole sample. They are not decoded.
+	PartialBytes int
+
+	// Instrument is parsed from the 'inst' chunk, if present.
+	Instrument *Instrument
+
+	// Sampler is parsed from the 'smpl' chunk, if present.
+	Sampler *Sampler
+
+	// Peaks is parsed from the 'PEAK' chunk, if present, one per channel.
+	Peaks []PeakInfo
 }
 
 type Wav struct {
@@ -56,25 +96,78 @@ type Wav struct {
 	// The Data corresponding to BitsPerSample is populated, indexed by sample.
 	Data8  [][]uint8
 	Data16 [][]int16
+	Data32 [][]int32
 
 	// Data is always populated, indexed by sample. It is a copy of DataXX.
 	Data [][]int
+
+	// RawChunks holds the chunks ReadWav did not recognize, in file order.
+	RawChunks []RawChunk
+
+	// source and dataOffset locate the data of a Wav read by ReadWavLazy,
+	// and sourceHeader describes its layout there.
+	source       io.ReadSeeker
+	dataOffset   int64
+	sourceHeader WavHeader
 }
 
+// StreamedWav reads the samples of a wav file as they are needed. As for
+// ReadWav, BitsPerSample describes the samples ReadSamples returns, while
+// BlockAlign and ChunkSize keep the layout of the data in the file.
 type StreamedWav struct {
 	WavHeader
 	io.Reader
+
+	// data limits Reader to the data chunk and counts what is left of it.
+	data *io.LimitedReader
+
+	// dataOffset is the offset of the data chunk body from the start of
+	// the file.
+	dataOffset int64
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// sniff returns a descriptive error if b starts like a common audio format
+// other than WAV.
+func sniff(b []byte) error {
+	switch {
+	case bytes.HasPrefix(b, []byte("OggS")):
+		return newError(ErrNotRIFF, "wav: input is an Ogg stream, not WAV")
+	case bytes.HasPrefix(b, []byte("fLaC")):
+		return newError(ErrNotRIFF, "wav: input is a FLAC stream, not WAV")
+	case bytes.HasPrefix(b, []byte("FORM")):
+		// AIFF and AIFF-C are big-endian relatives of WAV.
+		return newError(ErrNotRIFF, "wav: input appears to be AIFF, not WAV")
+	case bytes.HasPrefix(b, []byte("ID3")),
+		len(b) >= 2 && b[0] == 0xff && b[1]&0xe0 == 0xe0:
+		return newError(ErrNotRIFF, "wav: input is an MP3 stream, not WAV")
+	}
+	return nil
 }
 
 func checkHeader(header []byte) error {
+	if err := sniff(header); err != nil {
+		return err
+	}
 	if len(header) < ExpectedHeaderSize {
-		return errors.New("wav: Invalid header size")
+		return newError(ErrTruncated, "wav: Invalid header size")
 	}
 	if string(header[0:4]) != "RIFF" {
-		return errors.New("wav: Header does not conatin 'RIFF'")
+		return newError(ErrNotRIFF, "wav: Header does not conatin 'RIFF'")
 	}
 	if string(header[8:12]) != "WAVE" {
-		return errors.New("wav: Header does not contain 'WAVE'")
+		return newError(ErrNotRIFF, "wav: Header does not contain 'WAVE'")
 	}
 	if string(header[12:16]) != "fmt " {
 		return errors.New("wav: Header does not contain 'fmt'")
@@ -91,6 +184,7 @@ func (wavHeader *WavHeader) setupWithHeaderData(header []byte) (err error) {
 		return
 	}
 
+	wavHeader.RIFFSize = bLEtoUint32(header, RIFFMarkerOffset+4)
 	wavHeader.AudioFormat = bLEtoUint16(header, AudioFormatOffset)
 	wavHeader.NumChannels = bLEtoUint16(header, NumChannelsOffset)
 	wavHeader.SampleRate = bLEtoUint32(header, SampleRateOffset)
@@ -99,26 +193,68 @@ func (wavHeader *WavHeader) setupWithHeaderData(header []byte) (err error) {
 	wavHeader.BitsPerSample = bLEtoUint16(header, BitsPerSampleOffset)
 	wavHeader.ChunkSize = bLEtoUint32(header, ChunkSizeOffset)
 	wavHeader.NumSamples = int(wavHeader.ChunkSize) / int(wavHeader.BlockAlign)
+	wavHeader.PartialBytes = int(wavHeader.ChunkSize) % int(wavHeader.BlockAlign)
 
 	return
 }
 
 // Returns a single sample laid out by channel e.g. [ch0, ch1, ...]
+// Each value is read from its container of BlockAlign/NumChannels bytes and
+// scaled to sampleBits, discarding the padding below the valid bits. Values
+// scaled to 8 bits are offset to unsigned, as in 8-bit files.
 func readSampleFromData(data []byte, sampleIndex int, header WavHeader) (sample []int) {
 	sample = make([]int, header.NumChannels)
+	size := header.containerBytes()
+	bits := header.sampleBits()
+	shift := uint(size*8) - uint(bits)
 
 	for channelIdx := 0; channelIdx < int(header.NumChannels); channelIdx++ {
-		if header.BitsPerSample == 8 {
-			sample[channelIdx] = int(data[sampleIndex*int(header.NumChannels)+channelIdx])
-		} else if header.BitsPerSample == 16 {
-			sample[channelIdx] = int(bLEtoInt16(data, 2*sampleIndex*int(header.NumChannels)+channelIdx))
+		offset := sampleIndex*int(header.BlockAlign) + channelIdx*size
+		if size == 1 {
+			sample[channelIdx] = int(data[offset])
+			continue
+		}
+
+		// Little-endian, sign extended from the most significant byte.
+		v := int(int8(data[offset+size-1]))
+		for i := size - 2; i >= 0; i-- {
+			v = v<<8 | int(data[offset+i])
+		}
+		v >>= shift
+		if bits == 8 {
+			v += 128
 		}
+		sample[channelIdx] = v
 	}
 
 	return
 }
 
-// ReadWav reads a wav file.
+// containerBytes returns the number of bytes holding each value of a sample.
+func (wavHeader WavHeader) containerBytes() int {
+	if wavHeader.NumChannels == 0 {
+		return 0
+	}
+	return int(wavHeader.BlockAlign) / int(wavHeader.NumChannels)
+}
+
+// sampleBits returns the number of bits samples are decoded to: the valid
+// bits rounded up to whole bytes, but no more than the container.
+func (wavHeader WavHeader) sampleBits() uint16 {
+	valid := wavHeader.BitsPerSample
+	if wavHeader.ValidBitsPerSample != 0 {
+		valid = wavHeader.ValidBitsPerSample
+	}
+	bits := (valid + 7) / 8 * 8
+	if container := uint16(wavHeader.containerBytes() * 8); bits > container {
+		bits = container
+	}
+	return bits
+}
+
+// ReadWav reads a wav file. IMA ADPCM files are decoded to 16-bit PCM, with
+// the header describing the decoded data. Other formats with a Codec
+// registered by RegisterCodec are decoded by it, keeping their header.
 func ReadWav(r io.Reader) (wav *Wav, err error) {
 	if r == nil {
 		return nil, errors.New("wav: Invalid Reader")
@@ -128,48 +264,404 @@ func ReadWav(r io.Reader) (wav *Wav, err error) {
 	if err != nil {
 		return nil, err
 	}
+	return readWav(bytes)
+}
+
+// ReadWavLimit is like ReadWav, but reads at most maxBytes from r, returning
+// an error matching ErrTooLarge for longer input. Use it to bound the memory
+// spent on untrusted input.
+func ReadWavLimit(r io.Reader, maxBytes int64) (*Wav, error) {
+	if r == nil {
+		return nil, errors.New("wav: Invalid Reader")
+	}
+
+	bytes, err := ioutil.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(bytes)) > maxBytes {
+		return nil, newError(ErrTooLarge, fmt.Sprintf("wav: input exceeds the limit of %d bytes", maxBytes))
+	}
+	return readWav(bytes)
+}
+
+// progressStep is how many bytes ReadWavProgress reads between callbacks
+// when the total size is unknown.
+const progressStep = 64 << 10
+
+// ReadWavProgress is like ReadWav, but calls progress as it reads r with the
+// number of bytes read so far, to drive a progress bar. total is the
+// expected size of the input, such as the size of the file, or 0 if it is
+// not known; progress is called about every 1% of total, or every 64 KiB
+// without one, and once more at the end of the input with the final count.
+// If progress is nil, ReadWavProgress behaves like ReadWav.
+func ReadWavProgress(r io.Reader, total int64, progress func(read int64)) (*Wav, error) {
+	if r == nil {
+		return nil, errors.New("wav: Invalid Reader")
+	}
+	if progress == nil {
+		progress = func(int64) {}
+	}
+
+	step := int64(progressStep)
+	if total > 0 {
+		step = max(total/100, 1)
+	}
+	pr := &progressReader{r: r, step: step, next: step, progress: progress}
+
+	var buf bytes.Buffer
+	if total > 0 && total <= math.MaxInt {
+		buf.Grow(int(total))
+	}
+	if _, err := buf.ReadFrom(pr); err != nil {
+		return nil, err
+	}
+	if pr.reported != pr.n {
+		progress(pr.n)
+	}
+	return readWav(buf.Bytes())
+}
+
+// progressReader calls progress each time another step bytes have been read
+// through it.
+type progressReader struct {
+	r        io.Reader
+	n        int64
+	step     int64
+	next     int64
+	reported int64
+	progress func(read int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+	if p.n >= p.next {
+		p.progress(p.n)
+		p.reported = p.n
+		p.next = p.n - p.n%p.step + p.step
+	}
+	return n, err
+}
+
+// StrictReadWav is like ReadWav, but returns an error unless the RIFF size
+// and the sizes of all chunks, with their pad bytes, exactly account for
+// the input. ReadWav tolerates trailing bytes and a cut-off data chunk;
+// StrictReadWav reports them as corruption.
+func StrictReadWav(r io.Reader) (*Wav, error) {
+	if r == nil {
+		return nil, errors.New("wav: Invalid Reader")
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkChunkSizes(b); err != nil {
+		return nil, err
+	}
+	return readWav(b)
+}
+
+// checkChunkSizes returns an error if the sizes in the RIFF file b do not add
+// up to its length.
+func checkChunkSizes(b []byte) error {
+	if len(b) < 12 || string(b[0:4]) != "RIFF" {
+		// Let parsing report what is wrong.
+		return nil
+	}
+	if size := bLEtoUint32(b, 4); int64(size) != int64(len(b))-8 {
+		return fmt.Errorf("wav: RIFF size %d does not match %d bytes of input", size, len(b)-8)
+	}
+
+	offset := int64(12)
+	for offset < int64(len(b)) {
+		if offset+8 > int64(len(b)) {
+			return newError(ErrTruncated, "wav: chunk header runs past the end of the file")
+		}
+		id := string(b[offset : offset+4])
+		size := int64(bLEtoUint32(b, int(offset)+4))
+		offset += 8 + size + size%2
+		if offset > int64(len(b)) {
+			return newError(ErrTruncated, "wav: chunk '"+id+"' runs past the end of the file")
+		}
+	}
+	return nil
+}
+
+// readWav decodes the complete wav file b.
+func readWav(b []byte) (wav *Wav, err error) {
+	wav = new(Wav)
+	data, err := wav.parseChunks(b)
+	if err != nil {
+		return nil, err
+	}
+
+	if wav.AudioFormat == formatIMAADPCM {
+		samples, err := decodeIMAADPCM(data, int(wav.NumChannels), int(wav.BlockAlign))
+		if err != nil {
+			return nil, err
+		}
+		header := wav.WavHeader
+		header.AudioFormat = formatPCM
+		header.BitsPerSample = 16
+		decoded := newWav(header, samples)
+		decoded.RawChunks = wav.RawChunks
+		return decoded, nil
+	}
+	if c, ok := lookupCodec(wav.AudioFormat); ok {
+		if err = wav.decodeWithCodec(c, data); err != nil {
+			return nil, err
+		}
+		return
+	}
+
+	if wav.AudioFormat != formatPCM {
+		return nil, newError(ErrUnsupportedFormat, "wav: no decoder for audio format")
+	}
+	wav.decode(data)
+
+	return
+}
+
+// OpenWav opens, reads and closes the wav file at path. Decoding errors are
+// returned as an *os.PathError naming path.
+func OpenWav(path string) (*Wav, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	wav, err := ReadWav(f)
+	if err != nil {
+		return nil, &os.PathError{Op: "decode", Path: path, Err: err}
+	}
+	return wav, nil
+}
+
+// ReadRawPCM reads headerless interleaved little-endian PCM data in the
+// given format.
+func ReadRawPCM(r io.Reader, sampleRate uint32, bits, channels uint16) (wav *Wav, err error) {
+	if r == nil {
+		return nil, errors.New("wav: Invalid Reader")
+	}
+	if bits != 8 && bits != 16 && bits != 24 && bits != 32 {
+		return nil, newError(ErrUnsupportedFormat, "wav: unsupported bits per sample")
+	}
+	if channels == 0 {
+		return nil, errors.New("wav: invalid number of channels")
+	}
+
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	wav = new(Wav)
+	wav.AudioFormat = 1
+	wav.NumChannels = channels
+	wav.SampleRate = sampleRate
+	wav.BitsPerSample = bits
+	wav.BlockAlign = channels * bits / 8
+	wav.ByteRate = sampleRate * uint32(wav.BlockAlign)
+	wav.NumSamples = len(bytes) / int(wav.BlockAlign)
+	wav.PartialBytes = len(bytes) % int(wav.BlockAlign)
+	wav.ChunkSize = uint32(len(bytes))
+	wav.decode(bytes)
+
+	return
+}
+
+// ReadWavN reads and decodes only the first d of the PCM wav file read from
+// r, or all of it if it is shorter, and stops reading there. The header of
+// the result describes the samples decoded.
+func ReadWavN(r io.Reader, d time.Duration) (wav *Wav, err error) {
+	if r == nil {
+		return nil, errors.New("wav: Invalid Reader")
+	}
+
+	wav = new(Wav)
+	if err = wav.WavHeader.readStreamHeader(r); err != nil {
+		return nil, err
+	}
+	if wav.AudioFormat != formatPCM {
+		return nil, newError(ErrUnsupportedFormat, "wav: no decoder for audio format")
+	}
+
+	n := min(samplesIn(d, wav.SampleRate), wav.NumSamples)
+	data := make([]byte, n*int(wav.BlockAlign))
+	read, err := io.ReadFull(r, data)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	// Like ReadWav, keep whatever whole samples a truncated file holds.
+	wav.NumSamples = read / int(wav.BlockAlign)
+	wav.ChunkSize = uint32(wav.NumSamples * int(wav.BlockAlign))
+	wav.PartialBytes = 0
+	wav.decode(data)
+
+	return
+}
+
+// samplesIn returns the number of whole samples at sampleRate that fit in d.
+func samplesIn(d time.Duration, sampleRate uint32) int {
+	if d <= 0 {
+		return 0
+	}
+	// Split d into whole seconds and the remainder so the products cannot
+	// overflow.
+	rate := int64(sampleRate)
+	return int(int64(d/time.Second)*rate + int64(d%time.Second)*rate/int64(time.Second))
+}
+
+// ReadWavLazy parses the header of a wav file and records where its data
+// begins, leaving Data empty until DecodeData is called.
+func ReadWavLazy(r io.ReadSeeker) (wav *Wav, err error) {
+	if r == nil {
+		return nil, errors.New("wav: Invalid Reader")
+	}
 
 	wav = new(Wav)
-	err = wav.WavHeader.setupWithHeaderData(bytes)
+	if err = wav.WavHeader.readStreamHeader(r); err != nil {
+		return nil, err
+	}
+	if wav.AudioFormat != formatPCM {
+		return nil, newError(ErrUnsupportedFormat, "wav: no decoder for audio format")
+	}
+
+	wav.dataOffset, err = r.Seek(0, io.SeekCurrent)
 	if err != nil {
 		return nil, err
 	}
+	wav.source = r
+	wav.sourceHeader = wav.WavHeader
 
-	data := bytes[ExpectedHeaderSize : int(wav.ChunkSize)+ExpectedHeaderSize]
+	return
+}
+
+// DecodeData reads and decodes the data of a Wav returned by ReadWavLazy,
+// resetting the header to the one read from the file. It may be called
+// again to decode the data afresh.
+func (wav *Wav) DecodeData() error {
+	if wav.source == nil {
+		return errors.New("wav: no data source to decode")
+	}
 
+	if _, err := wav.source.Seek(wav.dataOffset, io.SeekStart); err != nil {
+		return err
+	}
+	// Read no more than the file holds rather than trusting the header to
+	// size the buffer.
+	size := int64(wav.sourceHeader.ChunkSize)
+	data, err := ioutil.ReadAll(io.LimitReader(wav.source, size))
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) < size {
+		return newError(ErrTruncated, "wav: data chunk runs past the end of the file")
+	}
+	wav.WavHeader = wav.sourceHeader
+	wav.decode(data)
+
+	return nil
+}
+
+// decode populates Data and the typed Data field from the data chunk bytes.
+// Samples stored in a container wider than their valid bits are unpacked,
+// and the header updated to describe the unpacked layout.
+func (wav *Wav) decode(data []byte) {
 	wav.Data = make([][]int, wav.NumSamples)
+	for i := 0; i < wav.NumSamples; i++ {
+		wav.Data[i] = readSampleFromData(data, i, wav.WavHeader)
+	}
 
-	if wav.BitsPerSample == 8 {
-		wav.Data8 = make([][]uint8, wav.NumSamples)
-		for sampleIndex := 0; sampleIndex < wav.NumSamples; sampleIndex++ {
-			wav.Data8[sampleIndex] = make([]uint8, wav.NumChannels)
-		}
+	if bits := wav.sampleBits(); bits != wav.BitsPerSample || wav.BlockAlign != wav.NumChannels*bits/8 {
+		wav.BitsPerSample = bits
+		wav.WavHeader.Recompute()
+	}
+	wav.fillTyped()
+}
+
+// newWav returns a Wav holding data, deriving the size fields of header
+// from the data and its format.
+func newWav(header WavHeader, data [][]int) *Wav {
+	wav := &Wav{WavHeader: header, Data: data}
+	wav.Recompute()
+
+	return wav
+}
 
-		for i := 0; i < wav.NumSamples; i++ {
-			sample := readSampleFromData(data, i, wav.WavHeader)
-			wav.Data[i] = sample
+// Recompute derives BlockAlign and ByteRate of a PCM header from its
+// channels, sample width and rate, and ChunkSize from NumSamples. Headers of
+// other formats than PCM and IEEE float, such as those decoded by a Codec,
+// are left unchanged, since their blocks hold many samples.
+func (wavHeader *WavHeader) Recompute() {
+	switch wavHeader.AudioFormat {
+	case 0, formatPCM, formatIEEEFloat:
+	default:
+		return
+	}
+	wavHeader.BlockAlign = wavHeader.NumChannels * ((wavHeader.BitsPerSample + 7) / 8)
+	wavHeader.ByteRate = wavHeader.SampleRate * uint32(wavHeader.BlockAlign)
+	wavHeader.ChunkSize = uint32(wavHeader.NumSamples * int(wavHeader.BlockAlign))
+}
 
-			for ch := 0; ch < int(wav.NumChannels); ch++ {
-				wav.Data8[i][ch] = uint8(sample[ch])
+// Recompute makes the header of w consistent with Data after either has
+// been changed directly: NumSamples is set to the length of Data, the other
+// size fields are derived as by WavHeader.Recompute and the typed Data field
+// for BitsPerSample is rebuilt.
+func (wav *Wav) Recompute() {
+	wav.NumSamples = len(wav.Data)
+	wav.PartialBytes = 0
+	wav.WavHeader.Recompute()
+	wav.fillTyped()
+}
+
+// fillTyped populates the Data field corresponding to BitsPerSample from Data.
+func (wav *Wav) fillTyped() {
+	wav.Data8 = nil
+	wav.Data16 = nil
+	wav.Data32 = nil
+
+	if wav.BitsPerSample == 8 {
+		wav.Data8 = make([][]uint8, len(wav.Data))
+		for i, sample := range wav.Data {
+			wav.Data8[i] = make([]uint8, len(sample))
+			for ch, v := range sample {
+				wav.Data8[i][ch] = uint8(v)
 			}
 		}
 	} else if wav.BitsPerSample == 16 {
-		wav.Data16 = make([][]int16, wav.NumSamples)
-		for sampleIndex := 0; sampleIndex < wav.NumSamples; sampleIndex++ {
-			wav.Data16[sampleIndex] = make([]int16, wav.NumChannels)
+		wav.Data16 = make([][]int16, len(wav.Data))
+		for i, sample := range wav.Data {
+			wav.Data16[i] = make([]int16, len(sample))
+			for ch, v := range sample {
+				wav.Data16[i][ch] = int16(v)
+			}
 		}
-
-		for i := 0; i < wav.NumSamples; i++ {
-			sample := readSampleFromData(data, i, wav.WavHeader)
-			wav.Data[i] = sample
-
-			for ch := 0; ch < int(wav.NumChannels); ch++ {
-				wav.Data16[i][ch] = int16(sample[ch])
+	} else if wav.BitsPerSample == 32 {
+		wav.Data32 = make([][]int32, len(wav.Data))
+		for i, sample := range wav.Data {
+			wav.Data32[i] = make([]int32, len(sample))
+			for ch, v := range sample {
+				wav.Data32[i][ch] = int32(v)
 			}
 		}
 	}
+}
 
-	return
+// set stores v as the value of channel ch at sampleIndex, keeping Data and
+// the typed Data field in sync.
+func (wav *Wav) set(sampleIndex, ch, v int) {
+	wav.Data[sampleIndex][ch] = v
+	if wav.BitsPerSample == 8 {
+		wav.Data8[sampleIndex][ch] = uint8(v)
+	} else if wav.BitsPerSample == 16 {
+		wav.Data16[sampleIndex][ch] = int16(v)
+	} else if wav.BitsPerSample == 32 {
+		wav.Data32[sampleIndex][ch] = int32(v)
+	}
 }
 
 // Constructs a StreamedWav which can be read using ReadSamples
@@ -178,24 +670,55 @@ func StreamWav(reader io.Reader) (wav *StreamedWav, err error) {
 		return nil, errors.New("wav: Invalid Reader")
 	}
 
-	header := make([]byte, ExpectedHeaderSize)
-	_, err = reader.Read(header)
-	if err != nil {
-		return nil, err
-	}
-
 	wav = new(StreamedWav)
-	err = wav.setupWithHeaderData(header)
-	if err != nil {
+	counter := &countingReader{r: reader}
+	if err = wav.readStreamHeader(counter); err != nil {
 		return nil, err
 	}
+	wav.dataOffset = counter.n
+	wav.BitsPerSample = wav.sampleBits()
 
-	wav.Reader = reader
+	// Stop at the end of the data chunk rather than decoding any chunks that
+	// follow it as samples.
+	wav.data = &io.LimitedReader{R: reader, N: int64(wav.ChunkSize)}
+	wav.Reader = wav.data
 
 	return
 }
 
-// Returns an array of [channelIndex][sampleIndex]
+// ResumeStreamWav returns a StreamedWav that continues reading the data of a
+// wav file at position, as returned by Position, so that a long job can be
+// checkpointed and resumed. header and dataOffset are those of the
+// StreamedWav the position was taken from, and r reads the same file.
+func ResumeStreamWav(header WavHeader, r io.ReaderAt, dataOffset, position int64) (*StreamedWav, error) {
+	if header.BlockAlign == 0 || position < 0 || position > int64(header.ChunkSize) || position%int64(header.BlockAlign) != 0 {
+		return nil, errors.New("wav: invalid stream position")
+	}
+	n := int64(header.ChunkSize) - position
+	wav := &StreamedWav{
+		WavHeader:  header,
+		data:       &io.LimitedReader{R: io.NewSectionReader(r, dataOffset+position, n), N: n},
+		dataOffset: dataOffset,
+	}
+	wav.Reader = wav.data
+	return wav, nil
+}
+
+// Position returns the number of bytes of the data chunk read so far.
+func (wav *StreamedWav) Position() int64 {
+	if wav.data == nil {
+		return 0
+	}
+	return int64(wav.ChunkSize) - wav.data.N
+}
+
+// DataOffset returns the offset of the data chunk body from the start of
+// the file, for use with ResumeStreamWav.
+func (wav *StreamedWav) DataOffset() int64 {
+	return wav.dataOffset
+}
+
+// Returns an array of [sampleIndex][channelIndex]
 // The number of samples returned may be less than the amount requested
 // depending on the amount of data available.
 func (wav *StreamedWav) ReadSamples(numSamples int) (samples [][]int, err error) {
@@ -205,7 +728,7 @@ func (wav *StreamedWav) ReadSamples(numSamples int) (samples [][]int, err error)
 		return
 	}
 	if amountRead%int(wav.BlockAlign) != 0 {
-		err = errors.New("wav: Read an invalid amount of data")
+		err = newError(ErrTruncated, "wav: Read an invalid amount of data")
 		return
 	}
 
@@ -219,6 +742,47 @@ func (wav *StreamedWav) ReadSamples(numSamples int) (samples [][]int, err error)
 	return
 }
 
+// readFullSamples is like ReadSamples, but reads until it has numSamples
+// samples or the data ends, as io.ReadFull does, so that the short reads of
+// pipes and sockets neither split samples nor end a block early. At the end
+// of the data it returns io.EOF.
+func (wav *StreamedWav) readFullSamples(numSamples int) ([][]int, error) {
+	data := make([]byte, numSamples*int(wav.BlockAlign))
+	n, err := io.ReadFull(wav.Reader, data)
+	if err == io.ErrUnexpectedEOF {
+		if n%int(wav.BlockAlign) != 0 {
+			return nil, newError(ErrTruncated, "wav: data ends partway through a sample")
+		}
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([][]int, n/int(wav.BlockAlign))
+	for i := range samples {
+		samples[i] = readSampleFromData(data, i, wav.WavHeader)
+	}
+	return samples, nil
+}
+
+// ReadSamplesPlanar is like ReadSamples, but returns the samples indexed
+// [channelIndex][sampleIndex].
+func (wav *StreamedWav) ReadSamplesPlanar(numSamples int) ([][]int, error) {
+	samples, err := wav.ReadSamples(numSamples)
+	if err != nil {
+		return nil, err
+	}
+	planar := make([][]int, wav.NumChannels)
+	for ch := range planar {
+		planar[ch] = make([]int, len(samples))
+		for i, sample := range samples {
+			planar[ch][i] = sample[ch]
+		}
+	}
+	return planar, nil
+}
+
 // little-endian [4]byte to uint32 conversion
 func bLEtoUint32(b []byte, idx int) uint32 {
 	return uint32(b[idx+3])<<24 +
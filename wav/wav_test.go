@@ -1,9 +1,17 @@
 package wav
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
 	"math"
 	"os"
+	"reflect"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 const (
@@ -211,6 +219,27 @@ func TestReadWavFromFile(t *testing.T) {
 	}
 }
 
+func TestOpenWav(t *testing.T) {
+	wav, err := OpenWav(SmallWavFileName)
+	if err != nil {
+		t.Fatalf("OpenWav returned an error: %v", err)
+	}
+	testFile, err := os.Open(SmallWavFileName)
+	if err != nil {
+		t.Fatalf("Unable to run test, can't open test file '%s'", SmallWavFileName)
+	}
+	defer testFile.Close()
+	expected, err := ReadWav(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareWavs(t, expected, wav)
+
+	if _, err = OpenWav("test_files/missing.wav"); !os.IsNotExist(err) {
+		t.Fatalf("Expected a not exist error for a missing file, got %v", err)
+	}
+}
+
 func TestStreamWav(t *testing.T) {
 	wav, err := StreamWav(nil)
 	if wav != nil {
@@ -271,3 +300,692 @@ func TestStreamWav(t *testing.T) {
 		t.Fatal("Expected zero samples returned when reading past end of reader")
 	}
 }
+
+func TestStreamWavSkipsChunks(t *testing.T) {
+	// A metadata chunk whose text contains "data" followed by what looks
+	// like a chunk size, then an odd-sized chunk needing a pad byte.
+	file := riff(
+		chunk("bext", []byte("comment: data\x04\x00\x00\x00 copied")),
+		fmtChunk(2, 8000, 16),
+		chunk("odd ", []byte{1, 2, 3}),
+		chunk("data", []byte{1, 0, 2, 0, 3, 0, 4, 0}),
+		chunk("LIST", []byte("INFOdata")),
+	)
+
+	wav, err := StreamWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("StreamWav returned an error: %v", err)
+	}
+	if wav.NumChannels != 2 || wav.NumSamples != 2 || wav.ChunkSize != 8 {
+		t.Fatalf("Unexpected header %+v", wav.WavHeader)
+	}
+	samples, err := wav.ReadSamples(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]int{{1, 2}, {3, 4}}
+	if !reflect.DeepEqual(samples, expected) {
+		t.Fatalf("Expected samples %v, got %v", expected, samples)
+	}
+	if samples, err = wav.ReadSamples(1); err == nil || len(samples) != 0 {
+		t.Fatalf("Expected the chunk after the data not to be read as samples, got %v", samples)
+	}
+
+	lazy, err := ReadWavLazy(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadWavLazy returned an error: %v", err)
+	}
+	if err = lazy.DecodeData(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(lazy.Data, expected) {
+		t.Fatalf("Expected lazily decoded samples %v, got %v", expected, lazy.Data)
+	}
+}
+
+// sineData returns n mono 16-bit samples of a sine at freq Hz with the given
+// amplitude relative to full scale.
+func sineData(freq float64, sampleRate uint32, n int, amplitude float64) [][]int {
+	data := make([][]int, n)
+	for i := range data {
+		v := amplitude * 32767 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate))
+		data[i] = []int{int(math.Floor(v + 0.5))}
+	}
+	return data
+}
+
+// testWav returns a 16-bit PCM Wav at sampleRate holding data.
+func testWav(sampleRate uint32, data [][]int) *Wav {
+	channels := 1
+	if len(data) > 0 {
+		channels = len(data[0])
+	}
+	return newWav(WavHeader{
+		AudioFormat:   1,
+		NumChannels:   uint16(channels),
+		SampleRate:    sampleRate,
+		BitsPerSample: 16,
+	}, data)
+}
+
+func TestReadWavLazy(t *testing.T) {
+	testFile, err := os.Open(SmallWavFileName)
+	if err != nil {
+		t.Fatalf("Unable to run test, can't open test file '%s'", SmallWavFileName)
+	}
+	defer testFile.Close()
+
+	wav, err := ReadWavLazy(testFile)
+	if err != nil {
+		t.Fatalf("ReadWavLazy returned an error: %v", err)
+	}
+	performTestOfHeaderInitialization(t, wav.WavHeader, expectedHeaderDataForTestFile(SmallWavFileName))
+	if len(wav.Data) != 0 || len(wav.Data16) != 0 {
+		t.Fatal("Expected Data to be empty before DecodeData")
+	}
+
+	if err = wav.DecodeData(); err != nil {
+		t.Fatalf("DecodeData returned an error: %v", err)
+	}
+
+	if _, err = testFile.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ReadWav(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wav.Data) != len(expected.Data) || len(wav.Data16) != len(expected.Data16) {
+		t.Fatalf("Expected %d decoded samples. Got %d", len(expected.Data), len(wav.Data))
+	}
+	for i := range expected.Data {
+		if wav.Data[i][0] != expected.Data[i][0] {
+			t.Fatalf("Sample %d differs. Expected %d. Got %d", i, expected.Data[i][0], wav.Data[i][0])
+		}
+	}
+}
+
+func TestDecodeDataTwice(t *testing.T) {
+	var data bytes.Buffer
+	for _, v := range []int32{1, -1, 32767, -32768} {
+		write(&data, v<<16)
+	}
+	file := riff(extensibleFmtChunk(2, 48000, 32, 16, 3), chunk("data", data.Bytes()))
+
+	wav, err := ReadWavLazy(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadWavLazy returned an error: %v", err)
+	}
+	// Decoding unpacks the samples and the header with them, which must not
+	// change how the file is read the second time.
+	for i := 0; i < 2; i++ {
+		if err = wav.DecodeData(); err != nil {
+			t.Fatalf("DecodeData returned an error: %v", err)
+		}
+		if expected := [][]int{{1, -1}, {32767, -32768}}; !reflect.DeepEqual(wav.Data, expected) {
+			t.Fatalf("Decode %d: expected %v, got %v", i, expected, wav.Data)
+		}
+	}
+
+	// A data chunk claiming far more than the file holds is truncated.
+	huge := append([]byte(nil), file...)
+	copy(huge[len(huge)-20:len(huge)-16], []byte{0xf0, 0xff, 0xff, 0x7f})
+	if wav, err = ReadWavLazy(bytes.NewReader(huge)); err != nil {
+		t.Fatalf("ReadWavLazy returned an error: %v", err)
+	}
+	if err = wav.DecodeData(); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("Expected an error matching ErrTruncated, got %v", err)
+	}
+}
+
+func TestSamples(t *testing.T) {
+	wav := testWav(8000, [][]int{{-32768, 32767}, {0, -1}, {1234, -4321}})
+
+	s16 := Samples[int16](wav)
+	s32 := Samples[int32](wav)
+	if len(s16) != len(wav.Data) || len(s32) != len(wav.Data) {
+		t.Fatalf("Expected %d samples. Got %d and %d", len(wav.Data), len(s16), len(s32))
+	}
+	for i := range wav.Data {
+		for ch := range wav.Data[i] {
+			if s16[i][ch] != wav.Data16[i][ch] || int32(s16[i][ch]) != s32[i][ch] {
+				t.Fatalf("Sample %d channel %d differs: int16 %d, int32 %d, Data16 %d", i, ch, s16[i][ch], s32[i][ch], wav.Data16[i][ch])
+			}
+		}
+	}
+}
+
+func TestReadRawPCM(t *testing.T) {
+	raw := []byte{0x01, 0x00, 0xff, 0xff, 0x00, 0x80, 0xff, 0x7f, 0x34, 0x12}
+	wav, err := ReadRawPCM(bytes.NewReader(raw), 8000, 16, 2)
+	if err != nil {
+		t.Fatalf("ReadRawPCM returned an error: %v", err)
+	}
+	if wav.NumSamples != 2 || wav.BlockAlign != 4 || wav.ByteRate != 32000 {
+		t.Fatalf("Unexpected header: %+v", wav.WavHeader)
+	}
+	expected := [][]int{{1, -1}, {-32768, 32767}}
+	for i := range expected {
+		for ch := range expected[i] {
+			if wav.Data[i][ch] != expected[i][ch] || int(wav.Data16[i][ch]) != expected[i][ch] {
+				t.Fatalf("Sample %d channel %d. Expected %d. Got %d", i, ch, expected[i][ch], wav.Data[i][ch])
+			}
+		}
+	}
+
+	if _, err = ReadRawPCM(bytes.NewReader(raw), 8000, 12, 2); err == nil {
+		t.Fatal("Expected an error for unsupported bits per sample")
+	}
+}
+
+// chunk returns a RIFF chunk with the given id and body, padded to an even
+// length.
+func chunk(id string, body []byte) []byte {
+	b := append([]byte(id), byte(len(body)), byte(len(body)>>8), byte(len(body)>>16), byte(len(body)>>24))
+	b = append(b, body...)
+	if len(body)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// fmtChunk returns a PCM 'fmt ' chunk for the given format.
+func fmtChunk(channels uint16, sampleRate uint32, bits uint16) []byte {
+	var body bytes.Buffer
+	blockAlign := channels * bits / 8
+	write(&body, uint16(1))
+	write(&body, channels)
+	write(&body, sampleRate)
+	write(&body, sampleRate*uint32(blockAlign))
+	write(&body, blockAlign)
+	write(&body, bits)
+	return chunk("fmt ", body.Bytes())
+}
+
+// riff returns a wav file made of chunks.
+func riff(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return append([]byte("RIFF"), append([]byte{byte(len(body)), byte(len(body) >> 8), byte(len(body) >> 16), byte(len(body) >> 24)}, body...)...)
+}
+
+func TestReadWavInstrumentChunk(t *testing.T) {
+	file := riff(
+		fmtChunk(1, 8000, 16),
+		chunk("inst", []byte{60, 0xfb, 3, 48, 72, 1, 127}),
+		chunk("data", []byte{0x01, 0x00, 0x02, 0x00}),
+	)
+
+	wav, err := ReadWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadWav returned an error: %v", err)
+	}
+	if wav.Instrument == nil {
+		t.Fatal("Expected the 'inst' chunk to be parsed")
+	}
+	expected := Instrument{UnshiftedNote: 60, FineTune: -5, Gain: 3, LowNote: 48, HighNote: 72, LowVelocity: 1, HighVelocity: 127}
+	if *wav.Instrument != expected {
+		t.Fatalf("Instrument does not match. Expected %+v. Got %+v", expected, *wav.Instrument)
+	}
+	if wav.NumSamples != 2 || wav.Data[1][0] != 2 {
+		t.Fatalf("Expected data after the 'inst' chunk to decode, got %v", wav.Data)
+	}
+}
+
+func TestReadWavPeakChunk(t *testing.T) {
+	// Stereo samples peaking at 0.5 in sample 1 on the left and -0.25 in
+	// sample 2 on the right.
+	peak := []byte{1, 0, 0, 0, 0, 0, 0, 0}
+	for _, p := range []struct {
+		value    float32
+		position uint32
+	}{{0.5, 1}, {0.25, 2}} {
+		v := math.Float32bits(p.value)
+		peak = append(peak, byte(v), byte(v>>8), byte(v>>16), byte(v>>24), byte(p.position), 0, 0, 0)
+	}
+	file := riff(
+		fmtChunk(2, 8000, 16),
+		chunk("PEAK", peak),
+		chunk("data", []byte{0, 0, 0, 0, 0, 0x40, 0, 0xf0, 0, 0, 0, 0xe0}),
+	)
+
+	wav, err := ReadWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadWav returned an error: %v", err)
+	}
+	expected := []PeakInfo{{0.5, 1}, {0.25, 2}}
+	if !reflect.DeepEqual(wav.Peaks, expected) {
+		t.Fatalf("Peaks do not match. Expected %v. Got %v", expected, wav.Peaks)
+	}
+	for ch, p := range wav.Peaks {
+		scanned := math.Abs(wav.normalize(wav.Data[p.Position][ch]))
+		if math.Abs(scanned-float64(p.Value)) > 1e-3 {
+			t.Errorf("Channel %d: stored peak %v does not match scanned sample %v", ch, p.Value, scanned)
+		}
+	}
+	if math.Abs(wav.Peak()-0.5) > 1e-3 {
+		t.Errorf("Expected the stored peak to match a full scan, got %v", wav.Peak())
+	}
+	if len(wav.RawChunks) != 0 {
+		t.Errorf("Expected the 'PEAK' chunk not to be kept raw, got %v", wav.RawChunks)
+	}
+}
+
+func TestReadFloat32PeakChunk(t *testing.T) {
+	// PEAK chunks are usually carried by float files, after the data.
+	samples := []float32{0, 0, 0.5, -0.125, 0.25, -0.75}
+	var data bytes.Buffer
+	write(&data, samples)
+	peak := []byte{1, 0, 0, 0, 0, 0, 0, 0}
+	for _, p := range []struct {
+		value    float32
+		position uint32
+	}{{0.5, 1}, {0.75, 2}} {
+		v := math.Float32bits(p.value)
+		peak = append(peak, byte(v), byte(v>>8), byte(v>>16), byte(v>>24), byte(p.position), 0, 0, 0)
+	}
+	float := fmtChunk(2, 8000, 32)
+	float[8] = 3 // WAVE_FORMAT_IEEE_FLOAT
+	file := riff(float, chunk("data", data.Bytes()), chunk("PEAK", peak))
+
+	got, header, err := ReadFloat32(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadFloat32 returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(got, samples) {
+		t.Fatalf("Expected %v. Got %v", samples, got)
+	}
+	expected := []PeakInfo{{0.5, 1}, {0.75, 2}}
+	if !reflect.DeepEqual(header.Peaks, expected) {
+		t.Fatalf("Peaks do not match. Expected %v. Got %v", expected, header.Peaks)
+	}
+}
+
+func TestReadWavPartialSample(t *testing.T) {
+	// Two stereo 16-bit samples followed by 3 stray bytes and a pad byte.
+	file := riff(
+		fmtChunk(2, 8000, 16),
+		chunk("data", []byte{1, 0, 2, 0, 3, 0, 4, 0, 9, 9, 9}),
+		chunk("inst", []byte{60, 0, 0, 0, 127, 0, 127}),
+	)
+
+	wav, err := ReadWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadWav returned an error: %v", err)
+	}
+	if wav.NumSamples != 2 || len(wav.Data) != 2 || wav.PartialBytes != 3 {
+		t.Fatalf("Expected 2 whole samples and 3 partial bytes. Got %d samples and %d partial bytes", wav.NumSamples, wav.PartialBytes)
+	}
+	if wav.Data[1][1] != 4 {
+		t.Fatalf("Expected the last whole sample to decode, got %v", wav.Data[1])
+	}
+	if wav.Instrument == nil || wav.Instrument.UnshiftedNote != 60 {
+		t.Fatal("Expected the chunk after the odd-sized data chunk to be found")
+	}
+}
+
+func TestReadWavNonWavInput(t *testing.T) {
+	for _, test := range []struct {
+		input    []byte
+		expected string
+	}{
+		{append([]byte("OggS\x00\x02"), make([]byte, 60)...), "wav: input is an Ogg stream, not WAV"},
+		{append([]byte("fLaC\x00\x00\x00\x22"), make([]byte, 60)...), "wav: input is a FLAC stream, not WAV"},
+		{append([]byte("FORM\x00\x00\x00\x40AIFFCOMM"), make([]byte, 60)...), "wav: input appears to be AIFF, not WAV"},
+		{append([]byte("ID3\x04\x00"), make([]byte, 60)...), "wav: input is an MP3 stream, not WAV"},
+		{append([]byte{0xff, 0xfb, 0x90, 0x64}, make([]byte, 60)...), "wav: input is an MP3 stream, not WAV"},
+	} {
+		if _, err := ReadWav(bytes.NewReader(test.input)); err == nil || err.Error() != test.expected {
+			t.Fatalf("ReadWav: expected error %q. Got %v", test.expected, err)
+		}
+		if _, err := StreamWav(bytes.NewReader(test.input)); err == nil || err.Error() != test.expected {
+			t.Fatalf("StreamWav: expected error %q. Got %v", test.expected, err)
+		}
+	}
+}
+
+// pcmGUID is the sub-format GUID of PCM in WAVE_FORMAT_EXTENSIBLE.
+var pcmGUID = []byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}
+
+// extensibleFmtChunk returns a WAVE_FORMAT_EXTENSIBLE PCM 'fmt ' chunk.
+func extensibleFmtChunk(channels uint16, sampleRate uint32, bits, validBits uint16, channelMask uint32) []byte {
+	var body bytes.Buffer
+	blockAlign := channels * bits / 8
+	write(&body, uint16(formatExtensible))
+	write(&body, channels)
+	write(&body, sampleRate)
+	write(&body, sampleRate*uint32(blockAlign))
+	write(&body, blockAlign)
+	write(&body, bits)
+	write(&body, uint16(22))
+	write(&body, validBits)
+	write(&body, channelMask)
+	write(&body, pcmGUID)
+	return chunk("fmt ", body.Bytes())
+}
+
+func TestReadWav16BitIn32BitContainer(t *testing.T) {
+	expected := [][]int{{1, -1}, {32767, -32768}, {1234, -4321}}
+	var data bytes.Buffer
+	for _, sample := range expected {
+		for _, v := range sample {
+			write(&data, int32(v)<<16)
+		}
+	}
+	file := riff(extensibleFmtChunk(2, 48000, 32, 16, 3), chunk("data", data.Bytes()))
+
+	wav, err := ReadWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadWav returned an error: %v", err)
+	}
+	if wav.AudioFormat != formatPCM || wav.BitsPerSample != 16 || wav.ValidBitsPerSample != 16 || wav.BlockAlign != 4 {
+		t.Fatalf("Expected the header to describe 16-bit PCM. Got %+v", wav.WavHeader)
+	}
+	if wav.NumSamples != len(expected) || len(wav.Data16) != len(expected) {
+		t.Fatalf("Expected %d samples. Got %d", len(expected), wav.NumSamples)
+	}
+	for i := range expected {
+		for ch := range expected[i] {
+			if int(wav.Data16[i][ch]) != expected[i][ch] {
+				t.Fatalf("Sample %d channel %d. Expected %d. Got %d", i, ch, expected[i][ch], wav.Data16[i][ch])
+			}
+		}
+	}
+}
+
+func TestStreamWav24BitIn32BitContainer(t *testing.T) {
+	var data bytes.Buffer
+	for _, v := range []int32{8388607, -8388608} {
+		write(&data, v<<8)
+	}
+	file := riff(extensibleFmtChunk(1, 48000, 32, 24, 4), chunk("data", data.Bytes()))
+
+	wav, err := StreamWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("StreamWav returned an error: %v", err)
+	}
+	// The header describes the samples ReadSamples returns, so they can be
+	// normalized by it.
+	if wav.BitsPerSample != 24 || wav.BlockAlign != 4 {
+		t.Fatalf("Expected 24-bit samples in 4-byte blocks. Got %+v", wav.WavHeader)
+	}
+	samples, err := wav.ReadSamples(2)
+	if err != nil {
+		t.Fatalf("ReadSamples returned an error: %v", err)
+	}
+	if samples[0][0] != 8388607 || samples[1][0] != -8388608 {
+		t.Fatalf("Expected full scale 24-bit samples. Got %v", samples)
+	}
+}
+
+func TestReadWav8BitIn16BitContainer(t *testing.T) {
+	var data bytes.Buffer
+	for _, v := range []int8{-128, 0, 127} {
+		write(&data, int16(v)<<8)
+	}
+	file := riff(extensibleFmtChunk(1, 8000, 16, 8, 4), chunk("data", data.Bytes()))
+
+	wav, err := ReadWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadWav returned an error: %v", err)
+	}
+	if wav.BitsPerSample != 8 || len(wav.Data8) != 3 {
+		t.Fatalf("Expected 3 8-bit samples. Got %+v", wav.WavHeader)
+	}
+	// 8-bit samples are unsigned, with silence at 128.
+	for i, expected := range []uint8{0, 128, 255} {
+		if wav.Data8[i][0] != expected {
+			t.Fatalf("Sample %d. Expected %d. Got %d", i, expected, wav.Data8[i][0])
+		}
+	}
+}
+
+func TestRecompute(t *testing.T) {
+	wav := testWav(8000, [][]int{{1}, {2}, {3}})
+	for i, sample := range wav.Data {
+		wav.Data[i] = append(sample, -sample[0])
+	}
+	wav.Data = append(wav.Data, []int{4, -4})
+	wav.NumChannels = 2
+	wav.Recompute()
+
+	if wav.BlockAlign != 4 || wav.ByteRate != 32000 || wav.NumSamples != 4 || wav.ChunkSize != 16 {
+		t.Fatalf("Inconsistent header after Recompute: %+v", wav.WavHeader)
+	}
+	if len(wav.Data16) != 4 || wav.Data16[3][1] != -4 {
+		t.Fatalf("Expected Data16 to be rebuilt, got %v", wav.Data16)
+	}
+
+	header := WavHeader{NumChannels: 6, SampleRate: 48000, BitsPerSample: 24, NumSamples: 10}
+	header.Recompute()
+	if header.BlockAlign != 18 || header.ByteRate != 864000 || header.ChunkSize != 180 {
+		t.Fatalf("Inconsistent header after Recompute: %+v", header)
+	}
+}
+
+func TestReadWavN(t *testing.T) {
+	testFile, err := os.Open(SmallWavFileName)
+	if err != nil {
+		t.Fatalf("Unable to run test, can't open test file '%s'", SmallWavFileName)
+	}
+	defer testFile.Close()
+	full, err := ReadWav(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		d        time.Duration
+		expected int
+	}{
+		{100 * time.Millisecond, 4410},
+		{250 * time.Millisecond, 11025},
+		{0, 0},
+		{time.Hour, full.NumSamples},
+	} {
+		if _, err = testFile.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		wav, err := ReadWavN(testFile, test.d)
+		if err != nil {
+			t.Fatalf("%v: ReadWavN returned an error: %v", test.d, err)
+		}
+		if len(wav.Data) != test.expected || wav.NumSamples != test.expected {
+			t.Fatalf("%v: expected %d samples, got %d", test.d, test.expected, len(wav.Data))
+		}
+		for i := range wav.Data {
+			if wav.Data[i][0] != full.Data[i][0] {
+				t.Fatalf("%v: sample %d differs. Expected %d. Got %d", test.d, i, full.Data[i][0], wav.Data[i][0])
+			}
+		}
+	}
+}
+
+func TestStrictReadWav(t *testing.T) {
+	good := riff(fmtChunk(1, 8000, 16), chunk("data", []byte{1, 0, 2, 0}), chunk("odd ", []byte{1}))
+	if _, err := StrictReadWav(bytes.NewReader(good)); err != nil {
+		t.Fatalf("StrictReadWav of a consistent file returned an error: %v", err)
+	}
+
+	wrongRIFF := append([]byte(nil), good...)
+	wrongRIFF[4]++
+	trailing := append(append([]byte(nil), good...), 0, 0)
+	trailing[4] += 2
+	cutData := riff(fmtChunk(1, 8000, 16), chunk("data", []byte{1, 0, 2, 0}))
+	cutData = cutData[:len(cutData)-2]
+	cutData[4] -= 2
+	for _, input := range [][]byte{wrongRIFF, trailing, cutData} {
+		if _, err := ReadWav(bytes.NewReader(input)); err != nil {
+			t.Fatalf("Expected ReadWav to accept inconsistent sizes, got %v", err)
+		}
+		if _, err := StrictReadWav(bytes.NewReader(input)); err == nil {
+			t.Fatal("Expected StrictReadWav to reject inconsistent sizes")
+		}
+	}
+}
+
+func TestReadWavMissingFmtExtension(t *testing.T) {
+	expected := [][]int{{1, -1}, {32767, -32768}}
+	var data bytes.Buffer
+	for _, sample := range expected {
+		for _, v := range sample {
+			write(&data, int16(v))
+		}
+	}
+
+	for _, format := range []uint16{formatPCM, formatExtensible} {
+		// cbSize declares the 22 byte extension, but the chunk ends after it.
+		header := fmtChunk(2, 8000, 16)
+		binary.LittleEndian.PutUint16(header[8:], format)
+		header = append(header, 22, 0)
+		header[4] += 2
+		file := riff(header, chunk("data", data.Bytes()))
+
+		wav, err := ReadWav(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("Format %#x: ReadWav returned an error: %v", format, err)
+		}
+		if wav.AudioFormat != formatPCM || !reflect.DeepEqual(wav.Data, expected) {
+			t.Fatalf("Format %#x: expected PCM data %v, got format %#x and %v", format, expected, wav.AudioFormat, wav.Data)
+		}
+		streamed, err := StreamWav(bytes.NewReader(file))
+		if err != nil {
+			t.Fatalf("Format %#x: StreamWav returned an error: %v", format, err)
+		}
+		if streamed.AudioFormat != formatPCM || streamed.NumSamples != len(expected) {
+			t.Fatalf("Format %#x: unexpected StreamWav header %+v", format, streamed.WavHeader)
+		}
+	}
+}
+
+func TestReadSamplesPlanar(t *testing.T) {
+	file := riff(fmtChunk(3, 8000, 16), chunk("data", []byte{1, 0, 2, 0, 3, 0, 4, 0, 5, 0, 6, 0}))
+	wav, err := StreamWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	planar, err := wav.ReadSamplesPlanar(10)
+	if err != nil {
+		t.Fatalf("ReadSamplesPlanar returned an error: %v", err)
+	}
+	expected := [][]int{{1, 4}, {2, 5}, {3, 6}}
+	if !reflect.DeepEqual(planar, expected) {
+		t.Fatalf("Expected [channel][sample] %v, got %v", expected, planar)
+	}
+	if _, err = wav.ReadSamplesPlanar(1); err == nil {
+		t.Fatal("Expected an error reading past the end of the data")
+	}
+}
+
+func TestResumeStreamWav(t *testing.T) {
+	file, err := ioutil.ReadFile(SmallWavFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	whole, err := ReadWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wav, err := StreamWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = wav.ReadSamples(100); err != nil {
+		t.Fatal(err)
+	}
+	position := wav.Position()
+	if position != 100*int64(wav.BlockAlign) {
+		t.Fatalf("Expected position %d, got %d", 100*int64(wav.BlockAlign), position)
+	}
+
+	resumed, err := ResumeStreamWav(wav.WavHeader, bytes.NewReader(file), wav.DataOffset(), position)
+	if err != nil {
+		t.Fatalf("ResumeStreamWav returned an error: %v", err)
+	}
+	samples, err := resumed.ReadSamples(whole.NumSamples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(samples, whole.Data[100:]) {
+		t.Fatal("Expected the resumed stream to continue with sample 100")
+	}
+	if resumed.Position() != int64(wav.ChunkSize) {
+		t.Fatalf("Expected the resumed stream to end at %d, got %d", wav.ChunkSize, resumed.Position())
+	}
+
+	if _, err = ResumeStreamWav(wav.WavHeader, bytes.NewReader(file), wav.DataOffset(), position+1); err == nil {
+		t.Fatal("Expected an error for a position within a sample")
+	}
+}
+
+func TestReadWavChannelMask(t *testing.T) {
+	file := riff(extensibleFmtChunk(6, 48000, 24, 24, 0x3f), chunk("data", make([]byte, 18)))
+	wav, err := ReadWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wav.ChannelMask != 0x3f {
+		t.Fatalf("Expected channel mask 0x3f, got %#x", wav.ChannelMask)
+	}
+	streamed, err := StreamWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streamed.ChannelMask != 0x3f {
+		t.Fatalf("Expected streamed channel mask 0x3f, got %#x", streamed.ChannelMask)
+	}
+
+	stereo, err := ToStereoITU(wav)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stereo.ChannelMask != 0 {
+		t.Fatalf("Expected the 5.1 mask to be cleared by the downmix, got %#x", stereo.ChannelMask)
+	}
+
+	if wav, err = ReadWav(bytes.NewReader(riff(fmtChunk(2, 8000, 16), chunk("data", nil)))); err != nil || wav.ChannelMask != 0 {
+		t.Fatalf("Expected no channel mask without the extension, got %#x (%v)", wav.ChannelMask, err)
+	}
+}
+
+func TestReadWavProgress(t *testing.T) {
+	// Large enough for several reports at the 64 KiB step.
+	file := riff(fmtChunk(2, 44100, 16), chunk("data", make([]byte, 400000)))
+	expected, err := ReadWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, total := range []int64{int64(len(file)), 0} {
+		var reports []int64
+		// Read in small pieces so that progress is reported along the way.
+		wav, err := ReadWavProgress(iotest.HalfReader(bytes.NewReader(file)), total, func(read int64) {
+			reports = append(reports, read)
+		})
+		if err != nil {
+			t.Fatalf("ReadWavProgress returned an error: %v", err)
+		}
+		compareWavs(t, expected, wav)
+
+		if len(reports) < 2 {
+			t.Fatalf("Total %d: expected several progress reports, got %v", total, reports)
+		}
+		for i := 1; i < len(reports); i++ {
+			if reports[i] <= reports[i-1] {
+				t.Fatalf("Total %d: progress did not increase: %v", total, reports)
+			}
+		}
+		if last := reports[len(reports)-1]; last != int64(len(file)) {
+			t.Fatalf("Total %d: expected the last report to be the file size %d, got %d", total, len(file), last)
+		}
+	}
+
+	wav, err := ReadWavProgress(bytes.NewReader(file), int64(len(file)), nil)
+	if err != nil {
+		t.Fatalf("ReadWavProgress without a callback returned an error: %v", err)
+	}
+	compareWavs(t, expected, wav)
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
+	"math"
 )
 
 type File struct {
@@ -22,11 +24,77 @@ func (f *File) WriteData(w io.Writer, data []byte) (err error) {
 	var buf bytes.Buffer
 	writeFmt(&buf, f)
 	writeChunk(&buf, "data", data)
+	writeRIFF(w, buf.Bytes())
+	return
+}
+
+// Write encodes w as a wav file with its current format and Data.
+func (w *Wav) Write(out io.Writer) (err error) {
+	defer func() {
+		if e, ok := recover().(error); ok {
+			err = e
+		}
+	}()
+	data, err := w.encodeData()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if w.AudioFormat == formatPCM || w.AudioFormat == 0 {
+		writeFmt(&buf, &File{w.SampleRate, w.BitsPerSample, w.NumChannels})
+	} else {
+		writeFmtHeader(&buf, w.WavHeader)
+	}
+	writeChunk(&buf, "data", data)
+	if inst := w.Instrument; inst != nil {
+		writeChunk(&buf, "inst", []byte{
+			inst.UnshiftedNote,
+			uint8(inst.FineTune),
+			uint8(inst.Gain),
+			inst.LowNote,
+			inst.HighNote,
+			inst.LowVelocity,
+			inst.HighVelocity,
+		})
+	}
+	if smpl := w.Sampler; smpl != nil {
+		var b bytes.Buffer
+		write(&b, []uint32{
+			smpl.Manufacturer,
+			smpl.Product,
+			smpl.SamplePeriod,
+			smpl.MIDIUnityNote,
+			smpl.MIDIPitchFraction,
+			smpl.SMPTEFormat,
+			smpl.SMPTEOffset,
+			uint32(len(smpl.Loops)),
+			uint32(len(smpl.SamplerData)),
+		})
+		write(&b, smpl.Loops)
+		write(&b, smpl.SamplerData)
+		writeChunk(&buf, "smpl", b.Bytes())
+	}
+	if w.Peaks != nil {
+		var peak bytes.Buffer
+		write(&peak, uint32(1)) // version
+		write(&peak, uint32(0)) // timestamp
+		write(&peak, w.Peaks)
+		writeChunk(&buf, "PEAK", peak.Bytes())
+	}
+	for _, c := range w.RawChunks {
+		writeChunk(&buf, c.ID, c.Data)
+	}
+	writeRIFF(out, buf.Bytes())
+	return
+}
+
+// writeRIFF writes the RIFF header followed by chunks, the already encoded
+// chunks of a WAVE file.
+func writeRIFF(w io.Writer, chunks []byte) {
 	write(w, []byte("RIFF"))
-	write(w, uint32(buf.Len()))
+	write(w, uint32(4+len(chunks)))
 	write(w, []byte("WAVE"))
-	write(w, buf.Bytes())
-	return
+	write(w, chunks)
 }
 
 func writeFmt(w io.Writer, f *File) (err error) {
@@ -40,6 +108,19 @@ func writeFmt(w io.Writer, f *File) (err error) {
 	return writeChunk(w, "fmt ", b.Bytes())
 }
 
+// writeFmtHeader writes a 'fmt ' chunk holding the format fields of h as
+// they are.
+func writeFmtHeader(w io.Writer, h WavHeader) {
+	var b bytes.Buffer
+	write(&b, h.AudioFormat)
+	write(&b, h.NumChannels)
+	write(&b, h.SampleRate)
+	write(&b, h.ByteRate)
+	write(&b, h.BlockAlign)
+	write(&b, h.BitsPerSample)
+	writeChunk(w, "fmt ", b.Bytes())
+}
+
 func writeChunk(w io.Writer, id string, data []byte) (err error) {
 	if len(id) != 4 {
 		panic(errors.New("invalid chunk id"))
@@ -47,6 +128,9 @@ func writeChunk(w io.Writer, id string, data []byte) (err error) {
 	write(w, []byte(id))
 	write(w, uint32(len(data)))
 	write(w, data)
+	if len(data)%2 == 1 {
+		write(w, []byte{0})
+	}
 	return
 }
 
@@ -65,3 +149,135 @@ func write(w io.Writer, data interface{}) {
 		panic(err)
 	}
 }
+
+// WriteRawPCM writes the interleaved sample data of w to out with no RIFF
+// header, as it would appear in the data chunk of a wav file.
+func (w *Wav) WriteRawPCM(out io.Writer) error {
+	_, err := out.Write(w.encode())
+	return err
+}
+
+// encodeData returns the body of the data chunk of w: Data encoded as PCM,
+// or by the Encoder registered for AudioFormat.
+func (w *Wav) encodeData() ([]byte, error) {
+	if w.AudioFormat == formatPCM || w.AudioFormat == 0 {
+		return w.encode(), nil
+	}
+
+	e, ok := lookupEncoder(w.AudioFormat)
+	if !ok {
+		return nil, newError(ErrUnsupportedFormat, "wav: no encoder for audio format")
+	}
+	interleaved := make([]int, 0, len(w.Data)*int(w.NumChannels))
+	for _, sample := range w.Data {
+		interleaved = append(interleaved, sample...)
+	}
+	return e.Encode(interleaved, w.WavHeader)
+}
+
+// encode returns Data laid out as little-endian interleaved PCM.
+func (w *Wav) encode() []byte {
+	return encodePCM(w.Data, w.NumChannels, w.BitsPerSample)
+}
+
+// encodePCM returns data, indexed [sample][channel], laid out as
+// little-endian interleaved PCM of the given width.
+func encodePCM(data [][]int, channels, bits uint16) []byte {
+	size := int(bits) / 8
+	b := make([]byte, 0, len(data)*int(channels)*size)
+	for _, sample := range data {
+		for _, v := range sample {
+			for i := 0; i < size; i++ {
+				b = append(b, uint8(v>>(8*i)))
+			}
+		}
+	}
+	return b
+}
+
+// WavWriter streams PCM samples to an io.Writer that need not be seekable.
+type WavWriter struct {
+	w       io.Writer
+	header  WavHeader
+	total   int
+	written int
+}
+
+// NewWavWriterFixed writes the header of a PCM wav file of totalSamples
+// samples to w and returns a WavWriter to stream the samples with. Since the
+// sizes are known up front, the header is never rewritten and w need not be
+// seekable.
+func NewWavWriterFixed(w io.Writer, totalSamples int, sampleRate uint32, bits, channels uint16) (ww *WavWriter, err error) {
+	defer func() {
+		if e, ok := recover().(error); ok {
+			err = e
+		}
+	}()
+	if bits != 8 && bits != 16 && bits != 24 && bits != 32 {
+		return nil, newError(ErrUnsupportedFormat, "wav: unsupported bits per sample")
+	}
+	if channels == 0 || totalSamples < 0 {
+		return nil, errors.New("wav: invalid format")
+	}
+	// The RIFF size, which counts all but the first 8 bytes of the file, must
+	// fit in 32 bits.
+	size := int64(totalSamples) * int64(channels) * int64(bits/8)
+	if size+size%2 > math.MaxUint32-(ExpectedHeaderSize-8) {
+		return nil, newError(ErrTooLarge, "wav: too many samples for a wav file")
+	}
+	ww = &WavWriter{w: w, total: totalSamples}
+	ww.header.AudioFormat = formatPCM
+	ww.header.NumChannels = channels
+	ww.header.SampleRate = sampleRate
+	ww.header.BitsPerSample = bits
+	ww.header.NumSamples = totalSamples
+	ww.header.Recompute()
+
+	var buf bytes.Buffer
+	writeFmt(&buf, &File{sampleRate, bits, channels})
+	write(&buf, []byte("data"))
+	write(&buf, ww.header.ChunkSize)
+	write(w, []byte("RIFF"))
+	write(w, uint32(4+buf.Len())+ww.header.ChunkSize+ww.header.ChunkSize%2)
+	write(w, []byte("WAVE"))
+	write(w, buf.Bytes())
+	return ww, nil
+}
+
+// WriteSamples writes samples, indexed [sample][channel]. It is an error to
+// write more samples than the total given to NewWavWriterFixed.
+func (ww *WavWriter) WriteSamples(samples [][]int) (err error) {
+	defer func() {
+		if e, ok := recover().(error); ok {
+			err = e
+		}
+	}()
+	if ww.written+len(samples) > ww.total {
+		return errors.New("wav: more samples written than declared")
+	}
+	for _, sample := range samples {
+		if len(sample) != int(ww.header.NumChannels) {
+			return errors.New("wav: sample has the wrong number of channels")
+		}
+	}
+	write(ww.w, encodePCM(samples, ww.header.NumChannels, ww.header.BitsPerSample))
+	ww.written += len(samples)
+	return nil
+}
+
+// Close finishes the file, returning an error if fewer samples were written
+// than declared. It does not close the underlying io.Writer.
+func (ww *WavWriter) Close() (err error) {
+	defer func() {
+		if e, ok := recover().(error); ok {
+			err = e
+		}
+	}()
+	if ww.written != ww.total {
+		return fmt.Errorf("wav: %d samples written, %d declared", ww.written, ww.total)
+	}
+	if ww.header.ChunkSize%2 == 1 {
+		write(ww.w, []byte{0})
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package wav
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRawPCM(t *testing.T) {
+	raw, err := ioutil.ReadFile(SmallWavFileName)
+	if err != nil {
+		t.Fatalf("Unable to run test, can't read test file '%s'", SmallWavFileName)
+	}
+	wav, err := ReadWav(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err = wav.WriteRawPCM(&out); err != nil {
+		t.Fatalf("WriteRawPCM returned an error: %v", err)
+	}
+	expected := raw[ExpectedHeaderSize : ExpectedHeaderSize+int(wav.ChunkSize)]
+	if !bytes.Equal(out.Bytes(), expected) {
+		t.Fatalf("Raw PCM does not match the data chunk. Expected %d bytes. Got %d", len(expected), out.Len())
+	}
+}
+
+// compareWavs fails t unless a and b have the same format and Data.
+func compareWavs(t *testing.T, a, b *Wav) {
+	if a.AudioFormat != b.AudioFormat || a.NumChannels != b.NumChannels || a.SampleRate != b.SampleRate ||
+		a.ByteRate != b.ByteRate || a.BlockAlign != b.BlockAlign || a.BitsPerSample != b.BitsPerSample ||
+		a.NumSamples != b.NumSamples {
+		t.Fatalf("Headers differ.\nExpected %+v\nGot      %+v", a.WavHeader, b.WavHeader)
+	}
+	for i := range a.Data {
+		for ch := range a.Data[i] {
+			if a.Data[i][ch] != b.Data[i][ch] {
+				t.Fatalf("Sample %d channel %d differs. Expected %d. Got %d", i, ch, a.Data[i][ch], b.Data[i][ch])
+			}
+		}
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	testFile, err := os.Open(SmallWavFileName)
+	if err != nil {
+		t.Fatalf("Unable to run test, can't open test file '%s'", SmallWavFileName)
+	}
+	defer testFile.Close()
+	small, err := ReadWav(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stereo8 := newWav(WavHeader{AudioFormat: 1, NumChannels: 2, SampleRate: 22050, BitsPerSample: 8},
+		[][]int{{0, 255}, {128, 127}, {1, 200}})
+	stereo8.Instrument = &Instrument{UnshiftedNote: 64, FineTune: -12, HighNote: 127, HighVelocity: 127}
+	stereo8.Peaks = []PeakInfo{{1, 0}, {0.9921875, 0}}
+	stereo8.Sampler = &Sampler{
+		SamplePeriod:  45351,
+		MIDIUnityNote: 64,
+		Loops: []Loop{
+			{CuePointID: 1, Start: 0, End: 2},
+			{CuePointID: 2, Type: 1, Start: 1, End: 2, PlayCount: 4},
+		},
+		SamplerData: []byte{1, 2, 3},
+	}
+
+	for _, wav := range []*Wav{small, stereo8} {
+		var buf bytes.Buffer
+		if err = wav.Write(&buf); err != nil {
+			t.Fatalf("Write returned an error: %v", err)
+		}
+		if riffSize := int(bLEtoUint32(buf.Bytes(), 4)); riffSize != buf.Len()-8 {
+			t.Fatalf("RIFF size is %d, expected %d", riffSize, buf.Len()-8)
+		}
+
+		reread, err := ReadWav(&buf)
+		if err != nil {
+			t.Fatalf("ReadWav of written data returned an error: %v", err)
+		}
+		compareWavs(t, wav, reread)
+		if (wav.Instrument == nil) != (reread.Instrument == nil) ||
+			wav.Instrument != nil && *wav.Instrument != *reread.Instrument {
+			t.Fatalf("Instrument differs. Expected %v. Got %v", wav.Instrument, reread.Instrument)
+		}
+		if !reflect.DeepEqual(wav.Sampler, reread.Sampler) {
+			t.Fatalf("Sampler differs. Expected %+v. Got %+v", wav.Sampler, reread.Sampler)
+		}
+		if !reflect.DeepEqual(wav.Peaks, reread.Peaks) {
+			t.Fatalf("Peaks differ. Expected %v. Got %v", wav.Peaks, reread.Peaks)
+		}
+	}
+}
+
+func TestWritePreservesRawChunks(t *testing.T) {
+	custom := []byte{1, 2, 3, 4, 5}
+	file := riff(
+		fmtChunk(1, 8000, 16),
+		chunk("LIST", []byte("INFOISFT\x04\x00\x00\x00go\x00\x00")),
+		chunk("data", []byte{0x01, 0x00, 0x02, 0x00}),
+		chunk("cust", custom),
+	)
+	wav, err := ReadWav(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wav.RawChunks) != 2 || wav.RawChunks[0].ID != "LIST" || wav.RawChunks[1].ID != "cust" {
+		t.Fatalf("Expected the LIST and cust chunks to be kept, got %v", wav.RawChunks)
+	}
+
+	var buf bytes.Buffer
+	if err = wav.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	reread, err := ReadWav(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reread.RawChunks) != 2 {
+		t.Fatalf("Expected 2 raw chunks after a round trip, got %d", len(reread.RawChunks))
+	}
+	for i, c := range wav.RawChunks {
+		if reread.RawChunks[i].ID != c.ID || !bytes.Equal(reread.RawChunks[i].Data, c.Data) {
+			t.Fatalf("Chunk %d differs. Expected %q %v. Got %q %v", i, c.ID, c.Data, reread.RawChunks[i].ID, reread.RawChunks[i].Data)
+		}
+	}
+	compareWavs(t, wav, reread)
+}
+
+func TestWriteWideIntegerPCM(t *testing.T) {
+	for _, test := range []struct {
+		bits uint16
+		data [][]int
+	}{
+		{24, [][]int{{-8388608, 8388607}, {0, -1}, {123456, -654321}}},
+		{32, [][]int{{math.MinInt32, math.MaxInt32}, {0, -1}, {123456789, -987654321}}},
+	} {
+		wav := newWav(WavHeader{AudioFormat: 1, NumChannels: 2, SampleRate: 48000, BitsPerSample: test.bits}, test.data)
+		var buf bytes.Buffer
+		if err := wav.Write(&buf); err != nil {
+			t.Fatalf("%d-bit: Write returned an error: %v", test.bits, err)
+		}
+		reread, err := ReadWav(&buf)
+		if err != nil {
+			t.Fatalf("%d-bit: ReadWav of written data returned an error: %v", test.bits, err)
+		}
+		compareWavs(t, wav, reread)
+		if test.bits == 32 {
+			for i, sample := range test.data {
+				for ch, v := range sample {
+					if reread.Data32[i][ch] != int32(v) {
+						t.Fatalf("Data32 sample %d channel %d differs. Expected %d. Got %d", i, ch, v, reread.Data32[i][ch])
+					}
+				}
+			}
+		}
+	}
+}
+
+// onlyWriter hides all methods but Write, so the writer under test cannot
+// seek.
+type onlyWriter struct {
+	io.Writer
+}
+
+func TestWavWriterFixed(t *testing.T) {
+	var buf bytes.Buffer
+	ww, err := NewWavWriterFixed(onlyWriter{&buf}, 3, 8000, 8, 1)
+	if err != nil {
+		t.Fatalf("NewWavWriterFixed returned an error: %v", err)
+	}
+	if err = ww.WriteSamples([][]int{{1}, {2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = ww.WriteSamples([][]int{{3}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = ww.WriteSamples([][]int{{4}}); err == nil {
+		t.Fatal("Expected writing past the declared total to fail")
+	}
+	if err = ww.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v", err)
+	}
+
+	if riffSize := int(bLEtoUint32(buf.Bytes(), 4)); riffSize != buf.Len()-8 {
+		t.Fatalf("RIFF size is %d, expected %d", riffSize, buf.Len()-8)
+	}
+	wav, err := ReadWav(&buf)
+	if err != nil {
+		t.Fatalf("ReadWav of written data returned an error: %v", err)
+	}
+	compareWavs(t, newWav(WavHeader{AudioFormat: 1, NumChannels: 1, SampleRate: 8000, BitsPerSample: 8}, [][]int{{1}, {2}, {3}}), wav)
+
+	ww, err = NewWavWriterFixed(onlyWriter{ioutil.Discard}, 2, 8000, 16, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ww.WriteSamples([][]int{{1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = ww.Close(); err == nil {
+		t.Fatal("Expected Close to fail when fewer samples than declared were written")
+	}
+
+	// 4 GiB of data does not fit in a RIFF file, and nothing is written.
+	buf.Reset()
+	if _, err = NewWavWriterFixed(&buf, 1<<29, 8000, 32, 2); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected an error matching ErrTooLarge, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("Expected no header to be written, got %d bytes", buf.Len())
+	}
+	maxSamples := (math.MaxUint32 - (ExpectedHeaderSize - 8)) / 4
+	if _, err = NewWavWriterFixed(ioutil.Discard, maxSamples, 8000, 32, 1); err != nil {
+		t.Fatalf("Expected the largest file to be allowed, got %v", err)
+	}
+	if _, err = NewWavWriterFixed(ioutil.Discard, maxSamples+1, 8000, 32, 1); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected an error matching ErrTooLarge, got %v", err)
+	}
+}